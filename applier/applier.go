@@ -8,6 +8,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/tengo"
 )
 
@@ -26,11 +27,20 @@ type Result struct {
 func Worker(ctx context.Context, targetGroups <-chan TargetGroup, results chan<- Result, printer *Printer) error {
 	var result Result
 	for tg := range targetGroups {
+		var grants *grantSet // lazily obtained; all targets in a group share an instance
+		var grantsErr error
 	TargetsInGroup:
 		for _, t := range tg { // iterate over each Target in the TargetGroup
 			// Get schema name from t.SchemaFromDir, NOT t.SchemaFromInstance, since
 			// t.SchemaFromInstance will be nil if the schema doesn't exist yet
 			schemaName := t.SchemaFromDir.Name
+
+			// If the user quit in interactive mode, don't bother processing any
+			// further targets
+			if printer.hasQuit() {
+				log.Warnf("Skipping %s %s at user request", t.Instance, schemaName)
+				continue
+			}
 			dryRun := t.Dir.Config.GetBool("dry-run")
 			brief := dryRun && t.Dir.Config.GetBool("brief")
 
@@ -86,9 +96,50 @@ func Worker(ctx context.Context, targetGroups <-chan TargetGroup, results chan<-
 				}
 			}
 
-			// Print DDL; if not dry-run, execute it
+			// Before running anything, confirm the user has sufficient privileges for
+			// all of the target's statements, to avoid failing partway through
+			if !dryRun && len(ddls) > 0 && t.Dir.Config.GetBool("verify-grants") {
+				if grants == nil && grantsErr == nil {
+					if grants, grantsErr = grantsForInstance(t.Instance); grantsErr != nil {
+						log.Warnf("Unable to verify privileges on %s, so DDL will be run without checking them first: %s", t.Instance, grantsErr)
+					}
+				}
+				if grants != nil {
+					if missing := grants.missing(ddls); len(missing) > 0 && len(grants.roles) > 0 {
+						// Privileges of roles are not shown by SHOW GRANTS, so the missing
+						// privileges may still be held
+						log.Warnf("User %s may lack privileges required by this push to %s %s, unless provided by roles %s: %s", grants.user, t.Instance, schemaName, strings.Join(grants.roles, ", "), strings.Join(missing, ", "))
+					} else if len(missing) > 0 {
+						result.SkipCount += len(ddls)
+						log.Errorf("Skipping %s %s: user %s lacks privileges required by this push: %s", t.Instance, schemaName, grants.user, strings.Join(missing, ", "))
+						continue TargetsInGroup
+					}
+				}
+			}
+
+			// Print DDL; if not dry-run, execute it. In interactive mode, the user is
+			// prompted before each statement is executed.
+			interactive := !dryRun && t.Dir.Config.GetBool("interactive")
+			var approveAll, quit bool
+		DDLsInTarget:
 			for i, ddl := range ddls {
-				printer.printDDL(ddl)
+				if interactive && !approveAll {
+					switch printer.confirmDDL(ddl) {
+					case answerNo:
+						result.SkipCount++
+						log.Infof("Skipping statement on %s %s at user request", t.Instance, schemaName)
+						continue DDLsInTarget
+					case answerQuit:
+						result.SkipCount += len(ddls) - i
+						quit = true
+						log.Warnf("Skipping %d remaining operations for %s %s at user request", len(ddls)-i, t.Instance, schemaName)
+						break DDLsInTarget
+					case answerAll:
+						approveAll = true
+					}
+				} else {
+					printer.printDDL(ddl)
+				}
 				if !dryRun {
 					if err := ddl.Execute(); err != nil {
 						log.Errorf("Error running DDL on %s %s: %s", t.Instance, schemaName, err)
@@ -104,6 +155,8 @@ func Worker(ctx context.Context, targetGroups <-chan TargetGroup, results chan<-
 
 			if targetStmtCount == 0 {
 				log.Infof("%s %s: No differences found\n", t.Instance, schemaName)
+			} else if quit {
+				log.Infof("%s %s: push stopped at user request\n", t.Instance, schemaName)
 			} else {
 				verb := "push"
 				if dryRun {
@@ -152,7 +205,7 @@ func StatementModifiersForDir(dir *fs.Dir) (mods tengo.StatementModifiers, err e
 	if mods.LockClause, err = dir.Config.GetEnum("alter-lock", "NONE", "SHARED", "EXCLUSIVE", "DEFAULT"); err != nil {
 		return
 	}
-	if mods.IgnoreTable, err = dir.Config.GetRegexp("ignore-table"); err != nil {
+	if mods.IgnoreTable, err = util.IgnoreTableRegexp(dir.Config); err != nil {
 		return
 	}
 	return
@@ -0,0 +1,185 @@
+package applier
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/skeema/tengo"
+)
+
+// dataBackup represents a snapshot of table data that will be destroyed by a
+// DDL statement. The snapshot is written to a local file prior to executing
+// the statement, to permit later restoration of the data.
+type dataBackup struct {
+	instance   *tengo.Instance
+	schemaName string
+	tableName  string
+	columns    []string // primary key columns followed by dropped columns, or all columns for DROP TABLE
+	format     string   // "CSV" or "SQL"
+	path       string
+}
+
+// newDataBackup returns a *dataBackup for the data destroyed by diff, based on
+// the backup-dir and backup-format options of target's dir. If the backup-dir
+// option is not set, or diff does not drop a table or any columns, nil is
+// returned. Backups are also never needed in dry-run mode.
+func newDataBackup(diff *tengo.TableDiff, target *Target) (*dataBackup, error) {
+	backupDir := target.Dir.Config.Get("backup-dir")
+	if backupDir == "" || target.SchemaFromInstance == nil || target.Dir.Config.GetBool("dry-run") {
+		return nil, nil
+	} else if diffType := diff.DiffType(); diffType != tengo.DiffTypeDrop && diffType != tengo.DiffTypeAlter {
+		return nil, nil
+	}
+	format, err := target.Dir.Config.GetEnum("backup-format", "CSV", "SQL")
+	if err != nil {
+		return nil, err
+	}
+
+	b := &dataBackup{
+		instance:   target.Instance,
+		schemaName: target.SchemaFromInstance.Name,
+		tableName:  diff.From.Name,
+		format:     format,
+	}
+	switch diff.DiffType() {
+	case tengo.DiffTypeDrop:
+		for _, col := range diff.From.Columns {
+			b.columns = append(b.columns, col.Name)
+		}
+	case tengo.DiffTypeAlter:
+		clauses, _ := diff.From.Diff(diff.To)
+		var dropped []string
+		for _, clause := range clauses {
+			if dc, ok := clause.(tengo.DropColumn); ok {
+				dropped = append(dropped, dc.Column.Name)
+			}
+		}
+		if len(dropped) == 0 {
+			return nil, nil
+		}
+		// Include the primary key so that dropped values can be matched back to
+		// their rows. Without a primary key, all columns are needed.
+		if diff.From.PrimaryKey == nil {
+			for _, col := range diff.From.Columns {
+				b.columns = append(b.columns, col.Name)
+			}
+		} else {
+			for _, col := range diff.From.PrimaryKey.Columns {
+				b.columns = append(b.columns, col.Name)
+			}
+			b.columns = append(b.columns, dropped...)
+		}
+	}
+
+	fileName := fmt.Sprintf("%s.%s.%s.%s.%s",
+		fileNameSafe(b.instance.String()),
+		fileNameSafe(b.schemaName),
+		fileNameSafe(b.tableName),
+		time.Now().Format("20060102150405"),
+		strings.ToLower(format))
+	b.path = filepath.Join(backupDir, fileName)
+	return b, nil
+}
+
+// write queries the data to back up, and writes it to b.path. It returns the
+// number of rows written. An error is returned if the file already exists.
+func (b *dataBackup) write() (rowCount int, err error) {
+	if err := os.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(b.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	db, err := b.instance.Connect(b.schemaName, "")
+	if err != nil {
+		return 0, err
+	}
+	escapedCols := make([]string, len(b.columns))
+	for n, col := range b.columns {
+		escapedCols[n] = tengo.EscapeIdentifier(col)
+	}
+	colList := strings.Join(escapedCols, ", ")
+	rows, err := db.Query(fmt.Sprintf("SELECT %s FROM %s", colList, tengo.EscapeIdentifier(b.tableName)))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	w := bufio.NewWriter(f)
+	var csvWriter *csv.Writer
+	if b.format == "CSV" {
+		csvWriter = csv.NewWriter(w)
+		csvWriter.Write(b.columns)
+	} else {
+		fmt.Fprintf(w, "-- Backup of %s.%s taken %s\n", tengo.EscapeIdentifier(b.schemaName), tengo.EscapeIdentifier(b.tableName), time.Now().Format(time.RFC3339))
+	}
+	values := make([]sql.RawBytes, len(b.columns))
+	scanArgs := make([]interface{}, len(b.columns))
+	for n := range values {
+		scanArgs[n] = &values[n]
+	}
+	record := make([]string, len(b.columns))
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return rowCount, err
+		}
+		for n, val := range values {
+			if val == nil && b.format == "CSV" {
+				record[n] = `\N`
+			} else if val == nil {
+				record[n] = "NULL"
+			} else if b.format == "CSV" {
+				record[n] = string(val)
+			} else {
+				record[n] = fmt.Sprintf("'%s'", escapeValue(string(val)))
+			}
+		}
+		if csvWriter != nil {
+			err = csvWriter.Write(record)
+		} else {
+			_, err = fmt.Fprintf(w, "INSERT INTO %s (%s) VALUES (%s);\n", tengo.EscapeIdentifier(b.tableName), colList, strings.Join(record, ", "))
+		}
+		if err != nil {
+			return rowCount, err
+		}
+		rowCount++
+	}
+	if err := rows.Err(); err != nil {
+		return rowCount, err
+	}
+	if csvWriter != nil {
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return rowCount, err
+		}
+	}
+	return rowCount, w.Flush()
+}
+
+// fileNameSafe returns name with any path separators and other problematic
+// characters replaced, so that it may be used as part of a file name without
+// escaping its directory.
+func fileNameSafe(name string) string {
+	replacer := strings.NewReplacer(":", "_", "/", "_", "\\", "_", "\x00", "_", "[", "", "]", "")
+	return replacer.Replace(name)
+}
+
+// escapeValue escapes a string for use inside of a single-quoted SQL string
+// literal.
+func escapeValue(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "'", `\'`, "\x00", `\0`, "\n", `\n`, "\r", `\r`, "\x1a", `\Z`)
+	return replacer.Replace(value)
+}
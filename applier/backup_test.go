@@ -0,0 +1,95 @@
+package applier
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestEscapeValue(t *testing.T) {
+	cases := map[string]string{
+		"plain":           "plain",
+		"it's":            `it\'s`,
+		`back\slash`:      `back\\slash`,
+		"two\nlines\r":    `two\nlines\r`,
+		"nul\x00ctrl\x1a": `nul\0ctrl\Z`,
+	}
+	for input, expected := range cases {
+		if actual := escapeValue(input); actual != expected {
+			t.Errorf("Expected escapeValue(%q) to return %q, instead found %q", input, expected, actual)
+		}
+	}
+}
+
+func TestNewDataBackup(t *testing.T) {
+	inst, err := tengo.NewInstance("mysql", "root:@tcp(127.0.0.1:3306)/")
+	if err != nil {
+		t.Fatalf("Unexpected error from NewInstance: %s", err)
+	}
+	withPK := func(table *tengo.Table) *tengo.Table {
+		table.PrimaryKey = &tengo.Index{Name: "PRIMARY", Columns: table.Columns[0:1], SubParts: []uint16{0}, PrimaryKey: true, Unique: true}
+		table.CreateStatement = table.GeneratedCreateStatement(tengo.FlavorMySQL57)
+		return table
+	}
+	from := protectTestSchema(
+		withPK(protectTestTable("orders", "id", "int(11)", "amount", "int(11)", "note", "varchar(20)", "status", "int(11)")),
+		protectTestTable("nopk", "a", "int(11)", "b", "int(11)"),
+		protectTestTable("../../etc/passwd", "a", "int(11)"),
+	)
+	to := protectTestSchema(
+		withPK(protectTestTable("orders", "id", "int(11)", "status", "int(11)", "created", "int(11)")),
+		protectTestTable("nopk", "a", "int(11)"),
+		protectTestTable("added", "a", "int(11)"),
+	)
+	target := &Target{
+		Instance:           inst,
+		Dir:                &fs.Dir{Path: "/var/tmp/fakedir", Config: getBaseConfig(t, "--backup-dir=/var/tmp/backups")},
+		SchemaFromInstance: from,
+		SchemaFromDir:      to,
+	}
+
+	expectColumns := map[string][]string{
+		"orders":           {"id", "amount", "note"},
+		"nopk":             {"a", "b"},
+		"../../etc/passwd": {"a"},
+		"added":            nil,
+	}
+	tableDiffs := tengo.NewSchemaDiff(from, to).TableDiffs
+	if len(tableDiffs) != len(expectColumns) {
+		t.Fatalf("Expected %d table diffs, instead found %d", len(expectColumns), len(tableDiffs))
+	}
+	for _, td := range tableDiffs {
+		name := td.ObjectKey().Name
+		b, err := newDataBackup(td, target)
+		if err != nil {
+			t.Fatalf("Unexpected error from newDataBackup on %s: %s", name, err)
+		}
+		expected := expectColumns[name]
+		if expected == nil {
+			if b != nil {
+				t.Errorf("Expected no backup for %s, instead found %+v", name, b)
+			}
+			continue
+		}
+		if b == nil {
+			t.Errorf("Expected backup for %s, instead found nil", name)
+			continue
+		}
+		if !reflect.DeepEqual(b.columns, expected) {
+			t.Errorf("Unexpected backup columns for %s: expected %v, found %v", name, expected, b.columns)
+		}
+		if filepath.Dir(b.path) != "/var/tmp/backups" || !strings.HasSuffix(b.path, ".csv") {
+			t.Errorf("Unexpected backup path for %s: %s", name, b.path)
+		}
+	}
+
+	// No backups in dry-run mode
+	target.Dir.Config = getBaseConfig(t, "--backup-dir=/var/tmp/backups --dry-run")
+	if b, err := newDataBackup(tableDiffs[0], target); b != nil || err != nil {
+		t.Errorf("Expected no backup in dry-run mode, instead found %+v, %v", b, err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/skeema/fs"
@@ -18,6 +19,12 @@ import (
 type DDLStatement struct {
 	stmt     string
 	shellOut *util.ShellOut
+	backup   *dataBackup
+
+	tableSize  int64 // only populated if needed by options
+	unsafe     bool  // true if statement is considered potentially destructive
+	objectKey  tengo.ObjectKey
+	privileges []string // privileges needed to run stmt directly; nil for shellouts
 
 	instance      *tengo.Instance
 	schemaName    string
@@ -33,6 +40,7 @@ func NewDDLStatement(diff tengo.ObjectDiff, mods tengo.StatementModifiers, targe
 	ddl = &DDLStatement{
 		instance:   target.Instance,
 		schemaName: target.SchemaFromDir.Name,
+		objectKey:  diff.ObjectKey(),
 	}
 
 	var tableSize int64
@@ -44,7 +52,7 @@ func NewDDLStatement(diff tengo.ObjectDiff, mods tengo.StatementModifiers, targe
 		ddl.schemaName = ""
 	case tengo.ObjectTypeTable:
 		// Obtain table size only if actually needed
-		needSize := anyOptChanged(target, "safe-below-size", "alter-wrapper-min-size") || wrapperUsesSize(target, "alter-wrapper", "ddl-wrapper")
+		needSize := anyOptChanged(target, "safe-below-size", "alter-wrapper-min-size") || wrapperUsesSize(target, "alter-wrapper", "ddl-wrapper") || target.Dir.Config.GetBool("interactive")
 		if diff.DiffType() != tengo.DiffTypeCreate && needSize {
 			if tableSize, err = ddl.getTableSize(target, diff.(*tengo.TableDiff).From); err != nil {
 				return nil, err
@@ -52,6 +60,13 @@ func NewDDLStatement(diff tengo.ObjectDiff, mods tengo.StatementModifiers, targe
 		}
 	}
 
+	ddl.tableSize = tableSize
+	safeMods := mods
+	safeMods.AllowUnsafe = false
+	if _, err := diff.Statement(safeMods); tengo.IsForbiddenDiff(err) {
+		ddl.unsafe = true
+	}
+
 	// If --safe-below-size option in use, enable additional statement modifier
 	// if the table's size is less than the supplied option value
 	safeBelowSize, err := target.Dir.Config.GetBytes("safe-below-size")
@@ -91,6 +106,24 @@ func NewDDLStatement(diff tengo.ObjectDiff, mods tengo.StatementModifiers, targe
 		}
 	}
 
+	// Protected tables and columns take precedence over --allow-unsafe and
+	// --safe-below-size. diff --brief only needs to know whether differences
+	// exist though, so protection is not enforced in that situation.
+	if !target.Dir.Config.GetBool("brief") || !target.Dir.Config.GetBool("dry-run") {
+		protection, err := target.Protection()
+		if err != nil {
+			return nil, err
+		}
+		if err := protection.Check(diff); err != nil {
+			stmt, _ := diff.Statement(mods)
+			if stmt == "" { // ignore-table takes precedence
+				return nil, nil
+			}
+			errorText := fmt.Sprintf("Destructive statement /* %s */ is not permitted, even with --allow-unsafe or --safe-below-size: %s. See --protect-table and --protect-column for more information.", stmt, err)
+			return nil, errors.New(errorText)
+		}
+	}
+
 	// Get the raw DDL statement as a string, handling errors and noops correctly
 	if ddl.stmt, err = diff.Statement(mods); tengo.IsForbiddenDiff(err) {
 		errorText := fmt.Sprintf("Destructive statement /* %s */ is considered unsafe. Use --allow-unsafe or --safe-below-size to permit this operation; see --help for more information.", ddl.stmt)
@@ -103,6 +136,20 @@ func NewDDLStatement(diff tengo.ObjectDiff, mods tengo.StatementModifiers, targe
 		return nil, nil
 	}
 
+	// With --soft-drop, tables are renamed out of the way instead of dropped, so
+	// that they can be recovered until `skeema purge` removes them. Otherwise,
+	// with --backup-dir, any data destroyed by the statement will be saved to a
+	// file prior to execution.
+	softDrop := otype == tengo.ObjectTypeTable && diff.DiffType() == tengo.DiffTypeDrop && target.Dir.Config.GetBool("soft-drop")
+	if softDrop {
+		trashName := util.TrashTableName(diff.ObjectKey().Name, time.Now())
+		ddl.stmt = fmt.Sprintf("RENAME TABLE %s TO %s", tengo.EscapeIdentifier(diff.ObjectKey().Name), tengo.EscapeIdentifier(trashName))
+	} else if otype == tengo.ObjectTypeTable {
+		if ddl.backup, err = newDataBackup(diff.(*tengo.TableDiff), target); err != nil {
+			return nil, err
+		}
+	}
+
 	// If adding foreign key constraints, use foreign_key_checks=1 if requested
 	if wrapper == "" && otype == tengo.ObjectTypeTable && diff.DiffType() == tengo.DiffTypeAlter &&
 		strings.Contains(ddl.stmt, "ADD CONSTRAINT") &&
@@ -118,8 +165,11 @@ func NewDDLStatement(diff tengo.ObjectDiff, mods tengo.StatementModifiers, targe
 		ddl.connectParams = "sql_mode=@@GLOBAL.sql_mode"
 	}
 
-	// Apply wrapper if relevant
-	if wrapper != "" {
+	// Apply wrapper if relevant. Privileges are only validated for statements
+	// run directly, since an external program may connect differently.
+	if wrapper == "" {
+		ddl.privileges = requiredPrivileges(diff, softDrop, ddl.backup != nil)
+	} else {
 		var socket, port, connOpts string
 		if ddl.instance.SocketPath != "" {
 			socket = ddl.instance.SocketPath
@@ -203,8 +253,17 @@ func (ddl *DDLStatement) String() string {
 }
 
 // Execute runs the DDL statement, either by running a SQL query against a DB,
-// or shelling out to an external program, as appropriate.
+// or shelling out to an external program, as appropriate. If the statement
+// destroys data and the backup-dir option is in use, the data is first saved
+// to a file.
 func (ddl *DDLStatement) Execute() error {
+	if ddl.backup != nil {
+		rowCount, err := ddl.backup.write()
+		if err != nil {
+			return fmt.Errorf("Unable to back up data to %s prior to running DDL: %s", ddl.backup.path, err)
+		}
+		log.Infof("Backed up %d rows of %s.%s to %s", rowCount, tengo.EscapeIdentifier(ddl.backup.schemaName), tengo.EscapeIdentifier(ddl.backup.tableName), ddl.backup.path)
+	}
 	if ddl.IsShellOut() {
 		return ddl.shellOut.Run()
 	}
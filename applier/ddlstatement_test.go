@@ -58,6 +58,13 @@ func (s ApplierIntegrationSuite) TestNewDDLStatement(t *testing.T) {
 		"alter-algorithm":        "INPLACE",
 		"alter-lock":             "NONE",
 		"safe-below-size":        "0",
+		"soft-drop":              "0",
+		"backup-dir":             "",
+		"interactive":            "0",
+		"brief":                  "0",
+		"dry-run":                "0",
+		"protect-table":          "",
+		"protect-column":         "",
 		"connect-options":        "",
 		"environment":            "production",
 	}
@@ -0,0 +1,301 @@
+package applier
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// grantSet represents the privileges held by the user connecting to an
+// instance, as parsed from the output of SHOW GRANTS.
+type grantSet struct {
+	user    string
+	entries []grantEntry
+	revokes []grantEntry // partial revokes, which are always schema-level
+	roles   []string     // roles granted to the user, whose privileges are not known
+}
+
+// grantEntry represents the privileges from a single GRANT statement, or a
+// single partial REVOKE statement.
+type grantEntry struct {
+	schema           *regexp.Regexp // nil for global privileges
+	objectName       string         // empty for global or schema-level privileges
+	objectType       tengo.ObjectType
+	privileges       map[string]bool
+	columnPrivileges map[string]bool // privileges only held on some columns of the object
+}
+
+// Regular expressions used in parsing SHOW GRANTS output. The object pattern
+// permits either backtick-quoted identifiers (with doubled backticks inside) or
+// bare identifiers, along with the * wildcard.
+var (
+	identPattern   = "(`(?:[^`]|``)+`|[^\\s.`]+|\\*)"
+	reGrant        = regexp.MustCompile("^GRANT (.+?) ON (?:(TABLE|FUNCTION|PROCEDURE) )?" + identPattern + "(?:\\." + identPattern + ")? TO ")
+	reRevoke       = regexp.MustCompile("^REVOKE (.+?) ON " + identPattern + "\\.\\* FROM ")
+	reRoleGrant    = regexp.MustCompile("^GRANT ((?:`[^`]*`@`[^`]*`|[^\\s,]+)(?:,\\s*(?:`[^`]*`@`[^`]*`|[^\\s,]+))*) TO ")
+	reColumnList   = regexp.MustCompile(`\s*\([^)]*\)$`)
+	reProxyGrant   = regexp.MustCompile(`^GRANT PROXY ON `)
+	reUsageGrant   = regexp.MustCompile(`^GRANT USAGE ON \*\.\* TO `)
+	reAllPrivsList = regexp.MustCompile(`^ALL(?: PRIVILEGES)?$`)
+)
+
+// grantsForInstance queries and parses the privileges of the user that
+// instance connects as. An error is returned if the grants cannot be
+// interpreted.
+func grantsForInstance(instance *tengo.Instance) (*grantSet, error) {
+	db, err := instance.Connect("", "")
+	if err != nil {
+		return nil, err
+	}
+	gs := &grantSet{}
+	if err := db.QueryRow("SELECT CURRENT_USER()").Scan(&gs.user); err != nil {
+		return nil, err
+	}
+	var lines []string
+	if err := db.Select(&lines, "SHOW GRANTS"); err != nil {
+		return nil, err
+	}
+	if err := gs.parse(lines); err != nil {
+		return nil, err
+	}
+	return gs, nil
+}
+
+// parse populates gs from the supplied lines of SHOW GRANTS output. Grants of
+// roles are recorded in gs.roles, since the privileges of roles are not shown.
+// An error is returned if any line cannot be interpreted.
+func (gs *grantSet) parse(lines []string) error {
+	for _, line := range lines {
+		if reProxyGrant.MatchString(line) || reUsageGrant.MatchString(line) {
+			continue
+		}
+		if matches := reRevoke.FindStringSubmatch(line); matches != nil {
+			entry := newGrantEntry(matches[1])
+			entry.schema = regexp.MustCompile("^" + regexp.QuoteMeta(unquoteIdentifier(matches[2])) + "$")
+			gs.revokes = append(gs.revokes, entry)
+			continue
+		}
+		matches := reGrant.FindStringSubmatch(line)
+		if matches == nil {
+			if roleMatches := reRoleGrant.FindStringSubmatch(line); roleMatches != nil {
+				for _, role := range strings.Split(roleMatches[1], ",") {
+					gs.roles = append(gs.roles, strings.TrimSpace(role))
+				}
+				continue
+			}
+			return fmt.Errorf("Unable to parse grant: %s", line)
+		}
+		entry := newGrantEntry(matches[1])
+		if matches[2] == "FUNCTION" {
+			entry.objectType = tengo.ObjectTypeFunc
+		} else if matches[2] == "PROCEDURE" {
+			entry.objectType = tengo.ObjectTypeProc
+		}
+
+		// "ON *" refers to the default schema, which is never set by Skeema. Only
+		// "ON *.*" refers to global privileges.
+		schema, object := matches[3], matches[4]
+		if schema == "*" && object == "" {
+			continue
+		} else if schema != "*" {
+			entry.schema = schemaPatternRegexp(unquoteIdentifier(schema))
+		}
+		if object != "*" {
+			entry.objectName = unquoteIdentifier(object)
+		}
+		gs.entries = append(gs.entries, entry)
+	}
+	return nil
+}
+
+// newGrantEntry returns a grantEntry for a table object, with privileges
+// populated from the supplied privilege list of a GRANT or REVOKE statement.
+// Privileges followed by a column list are only held on those columns, and
+// are tracked separately from privileges on the entire object.
+func newGrantEntry(privList string) grantEntry {
+	entry := grantEntry{
+		privileges:       make(map[string]bool),
+		columnPrivileges: make(map[string]bool),
+		objectType:       tengo.ObjectTypeTable,
+	}
+	if reAllPrivsList.MatchString(privList) {
+		entry.privileges["ALL"] = true
+		return entry
+	}
+	for _, priv := range splitPrivileges(privList) {
+		if reColumnList.MatchString(priv) {
+			entry.columnPrivileges[reColumnList.ReplaceAllString(priv, "")] = true
+		} else {
+			entry.privileges[priv] = true
+		}
+	}
+	return entry
+}
+
+// splitPrivileges splits a comma-separated privilege list, ignoring any commas
+// inside of parenthesized column lists.
+func splitPrivileges(privList string) (privs []string) {
+	var depth, start int
+	var inQuote bool
+	for n, c := range privList {
+		switch {
+		case c == '`':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			privs = append(privs, strings.TrimSpace(privList[start:n]))
+			start = n + 1
+		}
+	}
+	return append(privs, strings.TrimSpace(privList[start:]))
+}
+
+// has returns true if gs includes the supplied privilege for the supplied
+// object in the supplied schema. Object-level grants only match objects of
+// the same type. Schema-level privileges, such as CREATE ROUTINE, should be
+// checked with a zero-value key.
+func (gs *grantSet) has(privilege, schemaName string, key tengo.ObjectKey) bool {
+	for _, revoke := range gs.revokes {
+		if (revoke.privileges[privilege] || revoke.privileges["ALL"]) && revoke.schema.MatchString(schemaName) {
+			return false
+		}
+	}
+	for _, entry := range gs.entries {
+		if !entry.privileges[privilege] && !entry.privileges["ALL"] {
+			continue
+		}
+		if entry.schema != nil && !entry.schema.MatchString(schemaName) {
+			continue
+		}
+		if entry.objectName != "" && (entry.objectName != key.Name || entry.objectType != key.Type) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// hasColumnLevel returns true if gs includes the supplied privilege for only
+// some columns of the supplied table.
+func (gs *grantSet) hasColumnLevel(privilege, schemaName string, key tengo.ObjectKey) bool {
+	for _, entry := range gs.entries {
+		if entry.columnPrivileges[privilege] && entry.objectName == key.Name && entry.objectType == key.Type && entry.schema != nil && entry.schema.MatchString(schemaName) {
+			return true
+		}
+	}
+	return false
+}
+
+// missing returns a description of each privilege required by ddls that gs
+// does not include. Duplicates are omitted.
+func (gs *grantSet) missing(ddls []*DDLStatement) (result []string) {
+	seen := make(map[string]bool)
+	for _, ddl := range ddls {
+		for _, priv := range ddl.privileges {
+			schemaName, key := ddl.schemaName, ddl.objectKey
+			if key.Type == tengo.ObjectTypeDatabase {
+				schemaName, key = key.Name, tengo.ObjectKey{}
+			} else if priv == "CREATE ROUTINE" {
+				key = tengo.ObjectKey{}
+			}
+			if gs.has(priv, schemaName, key) {
+				continue
+			}
+			var desc string
+			if key.Name == "" {
+				desc = fmt.Sprintf("%s on %s.*", priv, tengo.EscapeIdentifier(schemaName))
+			} else {
+				desc = fmt.Sprintf("%s on %s %s.%s", priv, key.Type, tengo.EscapeIdentifier(schemaName), tengo.EscapeIdentifier(key.Name))
+				if gs.hasColumnLevel(priv, schemaName, key) {
+					desc += " (only held on some columns)"
+				}
+			}
+			if !seen[desc] {
+				seen[desc] = true
+				result = append(result, desc)
+			}
+		}
+	}
+	return result
+}
+
+// requiredPrivileges returns the privileges needed to run a statement for
+// diff. softDrop indicates that a table drop will be performed by renaming the
+// table instead, and backup indicates that data will be read from the table
+// prior to running the statement.
+func requiredPrivileges(diff tengo.ObjectDiff, softDrop, backup bool) (privs []string) {
+	key := diff.ObjectKey()
+	switch key.Type {
+	case tengo.ObjectTypeDatabase:
+		switch diff.DiffType() {
+		case tengo.DiffTypeCreate:
+			privs = []string{"CREATE"}
+		case tengo.DiffTypeAlter:
+			privs = []string{"ALTER"}
+		case tengo.DiffTypeDrop:
+			privs = []string{"DROP"}
+		}
+	case tengo.ObjectTypeTable:
+		switch diff.DiffType() {
+		case tengo.DiffTypeCreate:
+			privs = []string{"CREATE"}
+		case tengo.DiffTypeAlter:
+			privs = []string{"ALTER", "CREATE", "INSERT"}
+		case tengo.DiffTypeDrop:
+			if softDrop {
+				privs = []string{"ALTER", "DROP", "CREATE", "INSERT"}
+			} else {
+				privs = []string{"DROP"}
+			}
+		}
+		if backup {
+			privs = append(privs, "SELECT")
+		}
+	case tengo.ObjectTypeProc, tengo.ObjectTypeFunc:
+		switch diff.DiffType() {
+		case tengo.DiffTypeCreate:
+			privs = []string{"CREATE ROUTINE"}
+		case tengo.DiffTypeDrop:
+			privs = []string{"ALTER ROUTINE"}
+		}
+	}
+	return privs
+}
+
+// unquoteIdentifier strips backticks from a possibly-quoted identifier.
+func unquoteIdentifier(ident string) string {
+	if len(ident) > 1 && ident[0] == '`' && ident[len(ident)-1] == '`' {
+		return strings.Replace(ident[1:len(ident)-1], "``", "`", -1)
+	}
+	return ident
+}
+
+// schemaPatternRegexp converts a schema name from a GRANT statement into a
+// regular expression. Unescaped _ and % characters are wildcards in this
+// context, while backslash-escaped ones are literals.
+func schemaPatternRegexp(pattern string) *regexp.Regexp {
+	var b bytes.Buffer
+	b.WriteString("^")
+	for n := 0; n < len(pattern); n++ {
+		switch c := pattern[n]; {
+		case c == '\\' && n+1 < len(pattern):
+			n++
+			b.WriteString(regexp.QuoteMeta(pattern[n : n+1]))
+		case c == '_':
+			b.WriteString(".")
+		case c == '%':
+			b.WriteString(".*")
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[n : n+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
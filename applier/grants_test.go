@@ -0,0 +1,162 @@
+package applier
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestGrantSetParse(t *testing.T) {
+	lines := []string{
+		"GRANT USAGE ON *.* TO `skeema`@`%`",
+		"GRANT SELECT, INSERT, CREATE, ALTER ON `product\\_db`.* TO `skeema`@`%`",
+		"GRANT ALL PRIVILEGES ON `test_%`.* TO `skeema`@`%` WITH GRANT OPTION",
+		"GRANT DROP, UPDATE (`name`, `email`) ON `product_db`.`users` TO `skeema`@`%`",
+		"GRANT ALTER ROUTINE ON PROCEDURE `product_db`.`cleanup` TO `skeema`@`%`",
+		"GRANT PROXY ON ''@'' TO 'skeema'@'%' WITH GRANT OPTION",
+	}
+	gs := &grantSet{}
+	if err := gs.parse(lines); err != nil {
+		t.Fatalf("Unexpected error from parse: %s", err)
+	}
+	if len(gs.entries) != 4 {
+		t.Fatalf("Expected 4 entries, instead found %d", len(gs.entries))
+	}
+
+	users := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "users"}
+	orders := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "orders"}
+	cleanup := tengo.ObjectKey{Type: tengo.ObjectTypeProc, Name: "cleanup"}
+	cases := []struct {
+		priv     string
+		schema   string
+		key      tengo.ObjectKey
+		expected bool
+	}{
+		{"ALTER", "product_db", users, true},
+		{"ALTER", "productxdb", users, false},
+		{"DROP", "product_db", users, true},
+		{"DROP", "product_db", orders, false},
+		{"UPDATE", "product_db", users, false}, // column-level only
+		{"DROP", "test_1", orders, true},
+		{"CREATE ROUTINE", "test_anything", tengo.ObjectKey{}, true},
+		{"CREATE ROUTINE", "product_db", tengo.ObjectKey{}, false},
+		{"ALTER ROUTINE", "product_db", cleanup, true},
+		{"ALTER ROUTINE", "product_db", tengo.ObjectKey{Type: tengo.ObjectTypeFunc, Name: "cleanup"}, false},
+	}
+	for _, c := range cases {
+		if actual := gs.has(c.priv, c.schema, c.key); actual != c.expected {
+			t.Errorf("Expected has(%q, %q, %s) to return %t, instead found %t", c.priv, c.schema, c.key, c.expected, actual)
+		}
+	}
+
+	ddls := []*DDLStatement{
+		{schemaName: "product_db", objectKey: orders, privileges: []string{"DROP"}},
+		{schemaName: "product_db", objectKey: users, privileges: []string{"DROP"}},
+		{schemaName: "product_db", objectKey: cleanup, privileges: []string{"ALTER ROUTINE"}},
+		{schemaName: "product_db", objectKey: tengo.ObjectKey{Type: tengo.ObjectTypeFunc, Name: "f1"}, privileges: []string{"CREATE ROUTINE"}},
+		{schemaName: "product_db", objectKey: tengo.ObjectKey{Type: tengo.ObjectTypeFunc, Name: "f2"}, privileges: []string{"CREATE ROUTINE"}},
+	}
+	expected := []string{"DROP on table `product_db`.`orders`", "CREATE ROUTINE on `product_db`.*"}
+	if actual := gs.missing(ddls); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected missing() to return %v, instead found %v", expected, actual)
+	}
+
+	if err := gs.parse([]string{"GRANT DROP ON SCHEMA garbage"}); err == nil {
+		t.Error("Expected error parsing invalid grant, but err was nil")
+	}
+}
+
+func TestGrantSetParseColumnLevel(t *testing.T) {
+	lines := []string{
+		"GRANT SELECT (`a`, `b`), INSERT, UPDATE (`c`) ON `product_db`.`users` TO `skeema`@`%`",
+		"GRANT SELECT, DROP ON `product_db`.`orders` TO `skeema`@`%`",
+	}
+	gs := &grantSet{}
+	if err := gs.parse(lines); err != nil {
+		t.Fatalf("Unexpected error from parse: %s", err)
+	}
+	users := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "users"}
+	orders := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "orders"}
+	if !gs.has("INSERT", "product_db", users) || !gs.has("SELECT", "product_db", orders) {
+		t.Error("Expected table-level privileges to be present, but they were not")
+	}
+	if gs.has("SELECT", "product_db", users) || gs.has("UPDATE", "product_db", users) {
+		t.Error("Expected column-level privileges to not count as table-level privileges, but they did")
+	}
+	ddls := []*DDLStatement{
+		{schemaName: "product_db", objectKey: users, privileges: []string{"DROP", "SELECT"}},
+		{schemaName: "product_db", objectKey: orders, privileges: []string{"DROP", "SELECT"}},
+	}
+	expected := []string{"DROP on table `product_db`.`users`", "SELECT on table `product_db`.`users` (only held on some columns)"}
+	if actual := gs.missing(ddls); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected missing() to return %v, instead found %v", expected, actual)
+	}
+}
+
+func TestGrantSetParseRoles(t *testing.T) {
+	lines := []string{
+		"GRANT SELECT, INSERT, UPDATE, DELETE, CREATE, DROP, ALTER ON *.* TO `skeema`@`%`",
+		"REVOKE DROP, ALTER ON `mysql`.* FROM `skeema`@`%`",
+		"GRANT `app_role`@`%`,`ddl_role`@`localhost` TO `skeema`@`%`",
+	}
+	gs := &grantSet{}
+	if err := gs.parse(lines); err != nil {
+		t.Fatalf("Unexpected error from parse: %s", err)
+	}
+	if expected := []string{"`app_role`@`%`", "`ddl_role`@`localhost`"}; !reflect.DeepEqual(gs.roles, expected) {
+		t.Errorf("Expected roles %v, instead found %v", expected, gs.roles)
+	}
+	key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "users"}
+	cases := []struct {
+		priv     string
+		schema   string
+		expected bool
+	}{
+		{"DROP", "product_db", true},
+		{"DROP", "mysql", false},
+		{"ALTER", "mysql", false},
+		{"CREATE", "mysql", true},
+		{"DROP", "mysqlx", true},
+	}
+	for _, c := range cases {
+		if actual := gs.has(c.priv, c.schema, key); actual != c.expected {
+			t.Errorf("Expected has(%q, %q, %s) to return %t, instead found %t", c.priv, c.schema, key, c.expected, actual)
+		}
+	}
+}
+
+func TestRequiredPrivileges(t *testing.T) {
+	from := &tengo.Schema{Name: "product_db", CharSet: "latin1", Collation: "latin1_swedish_ci"}
+	to := &tengo.Schema{Name: "product_db", CharSet: "utf8mb4", Collation: "utf8mb4_general_ci"}
+	cases := []struct {
+		diff     tengo.ObjectDiff
+		expected []string
+	}{
+		{&tengo.DatabaseDiff{To: to}, []string{"CREATE"}},
+		{&tengo.DatabaseDiff{From: from, To: to}, []string{"ALTER"}},
+		{&tengo.DatabaseDiff{From: from}, []string{"DROP"}},
+	}
+	for n, c := range cases {
+		if actual := requiredPrivileges(c.diff, false, false); !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("cases[%d]: Expected requiredPrivileges to return %v, instead found %v", n, c.expected, actual)
+		}
+	}
+
+	// A user who can create schemas but not alter them may push a new schema
+	gs := &grantSet{}
+	if err := gs.parse([]string{"GRANT CREATE ON `product\\_db`.* TO `skeema`@`%`"}); err != nil {
+		t.Fatalf("Unexpected error from parse: %s", err)
+	}
+	ddls := []*DDLStatement{{
+		objectKey:  tengo.ObjectKey{Type: tengo.ObjectTypeDatabase, Name: "product_db"},
+		privileges: requiredPrivileges(cases[0].diff, false, false),
+	}}
+	if missing := gs.missing(ddls); len(missing) > 0 {
+		t.Errorf("Expected no missing privileges for creating a new schema, instead found %v", missing)
+	}
+	ddls[0].privileges = requiredPrivileges(cases[1].diff, false, false)
+	if missing := gs.missing(ddls); !reflect.DeepEqual(missing, []string{"ALTER on `product_db`.*"}) {
+		t.Errorf("Unexpected result from missing() for altering a schema: %v", missing)
+	}
+}
@@ -1,7 +1,10 @@
 package applier
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"strings"
 	"sync"
 
 	"github.com/skeema/tengo"
@@ -14,6 +17,8 @@ type Printer struct {
 	lastStdoutInstance string
 	lastStdoutSchema   string
 	seenInstance       map[string]bool
+	input              *bufio.Reader // only used in interactive mode
+	quit               bool          // true if user quit in interactive mode
 	*sync.Mutex
 }
 
@@ -35,6 +40,11 @@ func NewPrinter(briefMode bool) *Printer {
 func (p *Printer) printDDL(ddl *DDLStatement) {
 	p.Lock()
 	defer p.Unlock()
+	p.writeDDL(ddl)
+}
+
+// writeDDL outputs a DDLStatement to STDOUT. The caller must hold the lock.
+func (p *Printer) writeDDL(ddl *DDLStatement) {
 	instString := ddl.instance.String()
 
 	// Support diff --brief, which only outputs instances that have differences,
@@ -56,5 +66,76 @@ func (p *Printer) printDDL(ddl *DDLStatement) {
 		fmt.Printf("USE %s;\n", tengo.EscapeIdentifier(ddl.schemaName))
 		p.lastStdoutSchema = ddl.schemaName
 	}
+	if ddl.backup != nil {
+		fmt.Printf("-- Backing up affected data to %s\n", ddl.backup.path)
+	}
 	fmt.Print(ddl.String())
 }
+
+// answer represents a user's response to an interactive confirmation prompt.
+type answer int
+
+// Constants enumerating possible answers to an interactive confirmation
+const (
+	answerYes  answer = iota // execute this statement
+	answerNo                 // skip this statement
+	answerAll                // execute this statement and all remaining ones for the same target
+	answerQuit               // skip this statement and all remaining ones for all targets
+)
+
+// hasQuit returns true if the user quit in interactive mode, or if STDIN
+// reached EOF while prompting.
+func (p *Printer) hasQuit() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.quit
+}
+
+// confirmDDL outputs a DDLStatement to STDOUT, along with its table size and
+// whether it is considered unsafe, and then prompts the user on STDIN whether
+// to execute it. The lock is held for the duration of the prompt, so that
+// prompts from multiple workers are serialized. Once the user has quit, or if
+// STDIN reaches EOF, all subsequent calls return answerQuit without prompting.
+func (p *Printer) confirmDDL(ddl *DDLStatement) answer {
+	p.Lock()
+	defer p.Unlock()
+	if p.quit {
+		return answerQuit
+	}
+	if p.input == nil {
+		p.input = bufio.NewReader(os.Stdin)
+	}
+
+	p.writeDDL(ddl)
+	var classification string
+	if ddl.unsafe {
+		classification = "UNSAFE, potentially destructive"
+	} else {
+		classification = "safe"
+	}
+	if ddl.tableSize > 0 {
+		fmt.Printf("-- %s; table size %d bytes\n", classification, ddl.tableSize)
+	} else {
+		fmt.Printf("-- %s\n", classification)
+	}
+	for {
+		fmt.Print("Execute this statement? [y]es, [n]o, [a]ll remaining for this schema, [q]uit: ")
+		line, err := p.input.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y", "yes":
+			return answerYes
+		case "n", "no":
+			return answerNo
+		case "a", "all":
+			return answerAll
+		case "q", "quit":
+			p.quit = true
+			return answerQuit
+		}
+		if err != nil {
+			fmt.Println()
+			p.quit = true
+			return answerQuit
+		}
+	}
+}
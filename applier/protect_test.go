@@ -0,0 +1,95 @@
+package applier
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestNewDDLStatementProtected(t *testing.T) {
+	from := protectTestSchema(
+		protectTestTable("ledger", "amount", "int(11)"),
+		protectTestTable("orders", "amount", "int(11)", "note", "varchar(20)"),
+	)
+	to := protectTestSchema(protectTestTable("orders", "note", "varchar(20)"))
+	target := &Target{
+		Dir:                &fs.Dir{Path: "/var/tmp/fakedir"},
+		SchemaFromInstance: from,
+		SchemaFromDir:      to,
+	}
+
+	// Protection cannot be overridden by AllowUnsafe
+	mods := tengo.StatementModifiers{AllowUnsafe: true}
+	target.Dir.Config = getBaseConfig(t, "--allow-unsafe --protect-table=ledger --protect-column=amount")
+	if protection, err := target.Protection(); err != nil || protection.Table == nil || protection.Column == nil {
+		t.Fatalf("Unexpected result from Target.Protection: %+v, %v", protection, err)
+	}
+	diffs := tengo.NewSchemaDiff(from, to).ObjectDiffs()
+	if len(diffs) != 2 {
+		t.Fatalf("Expected 2 diffs, instead found %d", len(diffs))
+	}
+	for _, diff := range diffs {
+		if ddl, err := NewDDLStatement(diff, mods, target); ddl != nil || err == nil || !strings.Contains(err.Error(), "even with --allow-unsafe") {
+			t.Errorf("Expected %s to be forbidden, instead found ddl=%v err=%v", diff.ObjectKey(), ddl, err)
+		}
+	}
+
+	// ignore-table takes precedence over protection
+	mods.IgnoreTable = regexp.MustCompile(".")
+	for _, diff := range diffs {
+		if ddl, err := NewDDLStatement(diff, mods, target); ddl != nil || err != nil {
+			t.Errorf("Expected %s to be ignored, instead found ddl=%v err=%v", diff.ObjectKey(), ddl, err)
+		}
+	}
+
+	// diff --brief does not enforce protection
+	mods.IgnoreTable = nil
+	target = &Target{
+		Dir:                &fs.Dir{Path: "/var/tmp/fakedir", Config: getBaseConfig(t, "--allow-unsafe --protect-table=ledger --protect-column=amount --brief --dry-run")},
+		SchemaFromInstance: from,
+		SchemaFromDir:      to,
+	}
+	for _, diff := range diffs {
+		if ddl, err := NewDDLStatement(diff, mods, target); ddl == nil || err != nil {
+			t.Errorf("Expected %s to be permitted with --brief, instead found ddl=%v err=%v", diff.ObjectKey(), ddl, err)
+		}
+	}
+}
+
+func protectTestSchema(tables ...*tengo.Table) *tengo.Schema {
+	return &tengo.Schema{
+		Name:      "protecttest",
+		CharSet:   "latin1",
+		Collation: "latin1_swedish_ci",
+		Tables:    tables,
+	}
+}
+
+// protectTestTable returns a table with the supplied name, and columns
+// supplied as alternating pairs of column name and type.
+func protectTestTable(name string, colNamesAndTypes ...string) *tengo.Table {
+	table := &tengo.Table{
+		Name:               name,
+		Engine:             "InnoDB",
+		CharSet:            "latin1",
+		Collation:          "latin1_swedish_ci",
+		CollationIsDefault: true,
+	}
+	for n := 0; n < len(colNamesAndTypes); n += 2 {
+		col := &tengo.Column{
+			Name:     colNamesAndTypes[n],
+			TypeInDB: colNamesAndTypes[n+1],
+			Nullable: true,
+			Default:  tengo.ColumnDefaultNull,
+		}
+		if strings.HasPrefix(col.TypeInDB, "varchar") {
+			col.CharSet, col.Collation, col.CollationIsDefault = table.CharSet, table.Collation, true
+		}
+		table.Columns = append(table.Columns, col)
+	}
+	table.CreateStatement = table.GeneratedCreateStatement(tengo.FlavorMySQL57)
+	return table
+}
@@ -5,6 +5,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/skeema/workspace"
 	"github.com/skeema/tengo"
 )
@@ -18,6 +19,20 @@ type Target struct {
 	Dir                *fs.Dir
 	SchemaFromInstance *tengo.Schema
 	SchemaFromDir      *tengo.Schema
+	protection         *util.Protection // lazily obtained from Dir's config
+}
+
+// Protection returns the tables and columns protected by the configuration of
+// t's dir. The configuration is only parsed on the first call.
+func (t *Target) Protection() (util.Protection, error) {
+	if t.protection == nil {
+		protection, err := util.ProtectionForConfig(t.Dir.Config)
+		if err != nil {
+			return protection, err
+		}
+		t.protection = &protection
+	}
+	return *t.protection, nil
 }
 
 // TargetGroup represents a group of Targets that all have the same Instance.
@@ -256,6 +256,10 @@ func getBaseConfig(t *testing.T, cliFlags string) *mybase.Config {
 	cmd.AddOption(mybase.StringOption("ddl-wrapper", 'X', "", "Like --alter-wrapper, but applies to all DDL types (CREATE, DROP, ALTER)"))
 	cmd.AddOption(mybase.StringOption("safe-below-size", 0, "0", "Always permit destructive operations for tables below this size in bytes"))
 	cmd.AddOption(mybase.StringOption("concurrent-instances", 'c', "1", "Perform operations on this number of instances concurrently"))
+	cmd.AddOption(mybase.BoolOption("soft-drop", 0, false, "Rename tables to a trash name instead of dropping them; see `skeema purge`"))
+	cmd.AddOption(mybase.StringOption("backup-dir", 0, "", "Save data from dropped tables and columns to files in this dir before running DDL"))
+	cmd.AddOption(mybase.StringOption("backup-format", 0, "CSV", `Format for files written by --backup-dir (valid values: "CSV", "SQL")`))
+	cmd.AddOption(mybase.BoolOption("interactive", 0, false, "Prompt for confirmation before running each DDL statement"))
 	cmd.AddArg("environment", "production", false)
 	util.AddGlobalOptions(cmd)
 	return mybase.ParseFakeCLI(t, cmd, fmt.Sprintf("appliertest %s", cliFlags))
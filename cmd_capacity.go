@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Report auto-increment columns approaching their maximum value"
+	desc := `Examines tables on database instance(s), comparing each table's next
+auto-increment value to the maximum value permitted by the auto-increment
+column's type. Tables that have used at least --capacity-threshold percent of
+their auto-increment capacity are reported.
+
+In MySQL 8, information_schema caches each table's next auto-increment value
+by default. This command queries with information_schema_stats_expiry=0 to
+bypass that cache, so the reported values are current.
+
+This command exits with code 0 if no tables are above the threshold, 1 if at
+least one table is above the threshold, or 2+ if a fatal error occurred.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for processing. For example,
+running ` + "`" + `skeema capacity staging` + "`" + ` will apply config directives from the
+[staging] section of config files, as well as any sectionless directives at the
+top of the file. If no environment name is supplied, the default is
+"production".`
+
+	cmd := mybase.NewCommand("capacity", summary, desc, CapacityHandler)
+	cmd.AddOption(mybase.StringOption("capacity-threshold", 0, "75", "Report tables that have used at least this percentage of their auto-increment range"))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// autoIncUsage describes how much of a table's auto-increment range has been
+// used.
+type autoIncUsage struct {
+	instance   *tengo.Instance
+	schemaName string
+	table      *tengo.Table
+	column     *tengo.Column
+	max        uint64
+	percent    float64
+}
+
+// CapacityHandler is the handler method for `skeema capacity`
+func CapacityHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+
+	threshold, err := dir.Config.GetInt("capacity-threshold")
+	if err == nil && (threshold < 0 || threshold > 100) {
+		err = fmt.Errorf("capacity-threshold must be between 0 and 100")
+	}
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+
+	usages, skipCount := capacityWalker(dir, float64(threshold), 5)
+	if len(usages) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "INSTANCE\tSCHEMA\tTABLE\tCOLUMN\tTYPE\tNEXT VALUE\tMAX VALUE\tUSED")
+		for _, u := range usages {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%.1f%%\n", u.instance, u.schemaName, u.table.Name, u.column.Name, u.column.TypeInDB, u.table.NextAutoIncrement, u.max, u.percent)
+		}
+		w.Flush()
+	}
+
+	if skipCount > 0 {
+		var plural string
+		if skipCount > 1 {
+			plural = "s"
+		}
+		return NewExitValue(CodeFatalError, "Skipped %d operation%s due to error%s", skipCount, plural, plural)
+	} else if len(usages) > 0 {
+		var plural string
+		if len(usages) > 1 {
+			plural = "s"
+		}
+		return NewExitValue(CodeDifferencesFound, "Found %d table%s using at least %d%% of auto-increment capacity", len(usages), plural, threshold)
+	}
+	return nil
+}
+
+// capacityWalker examines auto-increment usage in all schemas that dir maps
+// to, and then recursively does the same for dir's subdirectories. It returns
+// usage information for tables at or above threshold percent, along with a
+// count of operations that were skipped due to errors.
+func capacityWalker(dir *fs.Dir, threshold float64, maxDepth int) (usages []autoIncUsage, skipCount int) {
+	if dir.Config.Changed("host") && dir.HasSchema() {
+		instances, err := dir.Instances()
+		if err != nil {
+			log.Warnf("Skipping %s: %s", dir, err)
+			skipCount++
+		}
+		ignoreTable, err := util.IgnoreTableRegexp(dir.Config)
+		if err != nil {
+			log.Warnf("Skipping %s: %s", dir, err)
+			return nil, skipCount + 1
+		}
+		for _, inst := range instances {
+			schemaNames, err := dir.SchemaNames(inst)
+			if err != nil {
+				log.Warnf("Skipping %s for %s: %s", inst, dir, err)
+				skipCount++
+				continue
+			}
+			for _, schemaName := range schemaNames {
+				schema, err := inst.Schema(schemaName)
+				if err != nil {
+					log.Errorf("Error examining %s %s: %s", inst, schemaName, err)
+					skipCount++
+					continue
+				}
+				if err := refreshNextAutoIncrements(inst, schema); err != nil {
+					log.Errorf("Error examining %s %s: %s", inst, schemaName, err)
+					skipCount++
+					continue
+				}
+				for _, table := range schema.Tables {
+					if ignoreTable != nil && ignoreTable.MatchString(table.Name) {
+						continue
+					}
+					if u, ok := tableAutoIncUsage(table); ok && u.percent >= threshold {
+						u.instance, u.schemaName = inst, schemaName
+						usages = append(usages, u)
+					}
+				}
+			}
+		}
+	}
+
+	subdirs, badCount, err := dir.Subdirs()
+	skipCount += badCount
+	if err != nil {
+		log.Warnf("Skipping subdirs of %s: %s", dir, err)
+		return usages, skipCount + 1
+	} else if len(subdirs) > 0 && maxDepth < 1 {
+		log.Warnf("Skipping subdirs of %s: max depth reached", dir)
+		return usages, skipCount + len(subdirs)
+	}
+	for _, sub := range subdirs {
+		subUsages, subSkipCount := capacityWalker(sub, threshold, maxDepth-1)
+		usages = append(usages, subUsages...)
+		skipCount += subSkipCount
+	}
+	return usages, skipCount
+}
+
+// refreshNextAutoIncrements updates the NextAutoIncrement of schema's tables
+// with uncached values. In flavors with a data dictionary, information_schema
+// caches these values for up to a day by default, unless the session sets
+// information_schema_stats_expiry=0. Other flavors do not cache them, in which
+// case this is a no-op.
+func refreshNextAutoIncrements(inst *tengo.Instance, schema *tengo.Schema) error {
+	if !inst.Flavor().HasDataDictionary() {
+		return nil
+	}
+	db, err := inst.Connect("", "information_schema_stats_expiry=0")
+	if err != nil {
+		return err
+	}
+	var rows []struct {
+		Name          string `db:"table_name"`
+		AutoIncrement uint64 `db:"auto_increment"`
+	}
+	query := `
+		SELECT table_name AS table_name, auto_increment AS auto_increment
+		FROM   information_schema.tables
+		WHERE  table_schema = ? AND auto_increment IS NOT NULL`
+	if err := db.Select(&rows, query, schema.Name); err != nil {
+		return err
+	}
+	tablesByName := schema.TablesByName()
+	for _, row := range rows {
+		if table := tablesByName[row.Name]; table != nil {
+			table.NextAutoIncrement = row.AutoIncrement
+		}
+	}
+	return nil
+}
+
+// tableAutoIncUsage returns information on how much of table's auto-increment
+// range has been used. If the table has no integer auto-increment column, ok
+// will be false.
+func tableAutoIncUsage(table *tengo.Table) (u autoIncUsage, ok bool) {
+	u.table = table
+	if u.column = util.AutoIncrementColumn(table); u.column == nil {
+		return u, false
+	}
+	if u.max, ok = util.AutoIncrementMax(u.column); !ok {
+		return u, false
+	}
+	var used uint64
+	if table.NextAutoIncrement > 0 {
+		used = table.NextAutoIncrement - 1
+	}
+	u.percent = 100 * float64(used) / float64(u.max)
+	return u, true
+}
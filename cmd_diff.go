@@ -56,7 +56,11 @@ func clonePushOptionsToDiff() {
 	hiddenRewrites := map[string]bool{
 		"brief":              false,
 		"dry-run":            true,
+		"backup-dir":         true,
+		"backup-format":      true,
 		"foreign-key-checks": true,
+		"interactive":        true,
+		"verify-grants":      true,
 	}
 
 	diffOptions := diff.Options()
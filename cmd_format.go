@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/linter"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Reformat table files to match canonical SHOW CREATE format"
+	desc := `Reformats the filesystem representation of tables and routines to match the
+format of SHOW CREATE, without checking for any linter problems. Statements
+containing skeema:disable comments are left as-is.
+
+This command relies on accessing database instances to test the SQL DDL. All DDL
+will be run against a temporary schema, with no impact on the real schema.
+
+With --check, no files are modified; instead, each statement that is not in the
+canonical format is reported. This is useful in CI.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for obtaining a database instance
+to test the SQL DDL against. For example, running ` + "`" + `skeema format staging` + "`" + ` will
+apply config directives from the [staging] section of config files, as well as
+any sectionless directives at the top of the file. If no environment name is
+supplied, the default is "production".
+
+An exit code of 0 will be returned if all files were already formatted properly;
+1 if some files were reformatted (or, with --check, would be reformatted); or 2+
+if any statements contained invalid SQL or another error occurred.`
+
+	cmd := mybase.NewCommand("format", summary, desc, FormatHandler)
+	cmd.AddOption(mybase.BoolOption("check", 0, false, "Report files that are not formatted canonically, without rewriting them"))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// FormatHandler is the handler method for `skeema format`
+func FormatHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+
+	result := formatWalker(dir, dir.Config.GetBool("check"), 5)
+	switch {
+	case len(result.Exceptions) > 0:
+		exitCode := CodeFatalError
+		for _, err := range result.Exceptions {
+			if _, ok := err.(linter.ConfigError); ok {
+				exitCode = CodeBadConfig
+			}
+		}
+		return NewExitValue(exitCode, "Skipped %d operations due to fatal errors", len(result.Exceptions))
+	case len(result.Errors) > 0:
+		return NewExitValue(CodeFatalError, "Found %d errors", len(result.Errors))
+	case len(result.FormatNotices) > 0 && dir.Config.GetBool("check"):
+		return NewExitValue(CodeDifferencesFound, "Found %d statements that are not formatted canonically", len(result.FormatNotices))
+	case len(result.FormatNotices) > 0:
+		return NewExitValue(CodeDifferencesFound, "")
+	}
+	return nil
+}
+
+// formatWalker checks the format of statements in dir, rewriting files as
+// needed unless check is true. It then recursively does the same for dir's
+// subdirs.
+func formatWalker(dir *fs.Dir, check bool, maxDepth int) (result *linter.Result) {
+	log.Infof("Formatting %s", dir)
+
+	// Connect to first defined instance, unless configured to use local Docker
+	var inst *tengo.Instance
+	if wsType, _ := dir.Config.GetEnum("workspace", "temp-schema", "docker"); wsType != "docker" || !dir.Config.Changed("flavor") {
+		var err error
+		if inst, err = dir.FirstInstance(); err != nil {
+			result = linter.BadConfigResult(dir, err)
+		}
+	}
+	opts, err := workspace.OptionsForDir(dir, inst)
+	if err != nil {
+		result = linter.BadConfigResult(dir, err)
+	}
+
+	if result == nil {
+		result = linter.FormatDir(dir, opts)
+	}
+	for _, err := range result.Exceptions {
+		log.Error(fmt.Errorf("Skipping schema in %s due to error: %s", dir.RelPath(), err))
+	}
+	for _, annotation := range result.Errors {
+		log.Error(annotation.MessageWithLocation())
+	}
+	for _, annotation := range result.FormatNotices {
+		if check {
+			log.Warnf("%s: SQL statement is not in canonical format", annotation.Statement.Location())
+			continue
+		}
+		length, err := annotation.Statement.FromFile.Rewrite()
+		if err != nil {
+			writeErr := fmt.Errorf("Unable to write to %s: %s", annotation.Statement.File, err)
+			log.Error(writeErr.Error())
+			result.Exceptions = append(result.Exceptions, writeErr)
+		} else {
+			log.Infof("Wrote %s (%d bytes) -- updated file to normalize format", annotation.Statement.File, length)
+		}
+	}
+	for _, dl := range result.DebugLogs {
+		log.Debug(dl)
+	}
+
+	var subdirErr error
+	if subdirs, badCount, err := dir.Subdirs(); err != nil {
+		subdirErr = fmt.Errorf("Cannot list subdirs of %s: %s", dir, err)
+	} else if len(subdirs) > 0 && maxDepth <= 0 {
+		subdirErr = fmt.Errorf("Not walking subdirs of %s: max depth reached", dir)
+	} else {
+		if badCount > 0 {
+			subdirErr = fmt.Errorf("Ignoring %d subdirs of %s with configuration errors", badCount, dir)
+		}
+		for _, sub := range subdirs {
+			result.Merge(formatWalker(sub, check, maxDepth-1))
+		}
+	}
+	if subdirErr != nil {
+		log.Error(subdirErr)
+		result.Exceptions = append(result.Exceptions, subdirErr)
+	}
+	return result
+}
@@ -11,6 +11,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/tengo"
 )
 
@@ -212,7 +213,7 @@ func PopulateSchemaDir(s *tengo.Schema, parentDir *fs.Dir, makeSubdir bool) erro
 	}
 
 	log.Infof("Populating %s", subPath)
-	ignoreTable, err := parentDir.Config.GetRegexp("ignore-table")
+	ignoreTable, err := util.IgnoreTableRegexp(parentDir.Config)
 	if err != nil {
 		return NewExitValue(CodeBadConfig, err.Error())
 	}
@@ -2,11 +2,15 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/fs"
 	"github.com/skeema/skeema/linter"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/skeema/workspace"
 	"github.com/skeema/tengo"
 )
@@ -15,7 +19,9 @@ func init() {
 	summary := "Verify table files and reformat them in a standardized way"
 	desc := `Reformats the filesystem representation of tables to match the format of SHOW
 CREATE TABLE. Verifies that all table files contain valid SQL in their CREATE
-TABLE statements.
+TABLE statements. To report formatting differences without rewriting any files,
+use --skip-normalize. To also rewrite statements to fix problems that have a
+mechanical fix, such as utf8 columns or MyISAM tables, use --fix.
 
 This command relies on accessing database instances to test the SQL DDL. All DDL
 will be run against a temporary schema, with no impact on the real schema.
@@ -33,6 +39,15 @@ some files were reformatted; or 2+ if any errors were emitted for any reason.`
 
 	cmd := mybase.NewCommand("lint", summary, desc, LintHandler)
 	linter.AddCommandOptions(cmd)
+	cmd.AddOption(mybase.StringOption("baseline", 0, "", "Path to file listing existing lint annotations to exclude from results"))
+	cmd.AddOption(mybase.BoolOption("write-baseline", 0, false, "Record all current lint annotations to the file specified by --baseline"))
+	cmd.AddOption(mybase.StringOption("against", 0, "", "Also check ALTERs that would be generated by pushing to this environment for operational risks, reported as plan-* linter problems"))
+	cmd.AddOption(mybase.StringOption("changed-since", 0, "", "Only lint dirs and files that have changed since this git ref"))
+	cmd.AddOption(mybase.BoolOption("changed-only", 0, false, "Only report annotations for objects that differ from the environment specified by --against"))
+	cmd.AddOption(mybase.BoolOption("cross-schema", 0, false, "Check for inconsistencies between the schemas of all linted dirs"))
+	cmd.AddOption(mybase.StringOption("target-flavor", 0, "", "Also check compatibility with this flavor, using a Docker workspace, in preparation for an upgrade"))
+	cmd.AddOption(mybase.BoolOption("fix", 0, false, "Rewrite CREATE statements to fix problems that have a mechanical fix, and then lint again"))
+	cmd.AddOption(mybase.StringOption("format", 0, "default", `Output annotations to STDOUT in a machine-readable format (valid values: "default", "json", "sarif", "checkstyle", "github")`))
 	cmd.AddArg("environment", "production", false)
 	CommandSuite.AddSubCommand(cmd)
 }
@@ -44,7 +59,86 @@ func LintHandler(cfg *mybase.Config) error {
 		return err
 	}
 
-	result := lintWalker(dir, 5)
+	format, err := dir.Config.GetEnum("format", append([]string{"default"}, linter.OutputFormats...)...)
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+
+	baselinePath := dir.Config.Get("baseline")
+	writeBaseline := dir.Config.GetBool("write-baseline")
+	var baseline *linter.Baseline
+	if baselinePath != "" {
+		if !filepath.IsAbs(baselinePath) {
+			baselinePath = filepath.Join(dir.Path, baselinePath)
+		}
+		if !writeBaseline {
+			if baseline, err = linter.ReadBaseline(baselinePath, dir.Path); err != nil {
+				return NewExitValue(CodeBadConfig, err.Error())
+			}
+		}
+	} else if writeBaseline {
+		return NewExitValue(CodeBadConfig, "Option write-baseline requires a file path to be specified via the baseline option")
+	}
+
+	lc := &lintContext{
+		baseline:     baseline,
+		changedSince: dir.Config.Get("changed-since"),
+		changedOnly:  dir.Config.GetBool("changed-only"),
+		fix:          dir.Config.GetBool("fix"),
+		crossSchema:  dir.Config.GetBool("cross-schema"),
+	}
+	// With --against, a separate config is needed for obtaining the target
+	// environment's hosts and schemas, since these come from the corresponding
+	// section of .skeema files
+	if against := dir.Config.Get("against"); against != "" {
+		cli := *cfg.CLI
+		cli.ArgValues = []string{against}
+		lc.againstCfg = mybase.NewConfig(&cli)
+		util.AddGlobalConfigFiles(lc.againstCfg)
+	} else if lc.changedOnly {
+		return NewExitValue(CodeBadConfig, "Option changed-only requires an environment to be specified via the against option")
+	}
+	if lc.changedSince != "" {
+		if lc.changedFiles, err = util.GitChangedFiles(dir.Path, lc.changedSince); err != nil {
+			return NewExitValue(CodeBadConfig, err.Error())
+		}
+	}
+	if targetFlavor := dir.Config.Get("target-flavor"); targetFlavor != "" {
+		if lc.targetFlavor = tengo.NewFlavor(targetFlavor); !lc.targetFlavor.Known() {
+			return NewExitValue(CodeBadConfig, "Option target-flavor must specify a known flavor, for example mysql:8.0 or mariadb:10.5")
+		}
+	}
+	if writeBaseline {
+		if lc.restricted() {
+			return NewExitValue(CodeBadConfig, "Option write-baseline cannot be combined with changed-since or changed-only")
+		}
+		lc.newBaseline = linter.NewBaseline(dir.Path)
+	}
+
+	result := lintWalker(dir, lc, 5)
+	if lc.crossSchema && len(result.Exceptions) == 0 {
+		result.Merge(lintCrossSchema(lc, result.Schemas))
+	}
+	// Baseline entries for objects that weren't linted would falsely appear fixed
+	if baseline != nil && !lc.restricted() {
+		for _, entry := range baseline.Fixed() {
+			log.Warnf("Baseline entry no longer found: %s. Use --write-baseline to remove it from %s.", entry, baselinePath)
+		}
+	}
+	if writeBaseline && len(result.Exceptions) == 0 {
+		if err := lc.newBaseline.Write(baselinePath); err != nil {
+			return NewExitValue(CodeCantCreate, "Unable to write baseline file: %s", err)
+		}
+		log.Infof("Wrote %s -- recorded %d existing annotations as baseline", baselinePath, len(lc.newBaseline.Entries))
+		result.Errors, result.Warnings = stripProblemAnnotations(result.Errors), stripProblemAnnotations(result.Warnings)
+	}
+	if format != "default" {
+		result.SortByFile()
+		if err := linter.WriteResult(os.Stdout, result, format, dir.Path); err != nil {
+			return NewExitValue(CodeFatalError, "Unable to write output: %s", err)
+		}
+	}
+
 	switch {
 	case len(result.Exceptions) > 0:
 		exitCode := CodeFatalError
@@ -64,9 +158,100 @@ func LintHandler(cfg *mybase.Config) error {
 	return nil
 }
 
-func lintWalker(dir *fs.Dir, maxDepth int) (result *linter.Result) {
-	log.Infof("Linting %s", dir)
+// stripProblemAnnotations returns the subset of annotations that are not tied
+// to a named problem, such as invalid SQL. These cannot be recorded in a
+// baseline.
+func stripProblemAnnotations(annotations []*linter.Annotation) (result []*linter.Annotation) {
+	for _, a := range annotations {
+		if a.Problem == "" {
+			result = append(result, a)
+		}
+	}
+	return result
+}
 
+// lintContext holds settings which affect every dir in a single run of
+// `skeema lint`.
+type lintContext struct {
+	baseline     *linter.Baseline // if non-nil, matching errors and warnings are excluded
+	newBaseline  *linter.Baseline // if non-nil, errors and warnings are added to it
+	againstCfg   *mybase.Config   // if non-nil, ALTERs to this environment are also checked
+	changedSince string           // git ref used to obtain changedFiles
+	changedFiles map[string]bool  // if non-nil, only these files are linted
+	changedOnly  bool             // if true, only objects differing from againstCfg's environment are linted
+	fix          bool             // if true, fixes are applied for any annotations that have one
+	crossSchema  bool             // if true, linted dirs are checked for consistency with one another
+	targetFlavor tengo.Flavor     // if known, dirs are also checked for compatibility with this flavor
+	lintedDirs   []*fs.Dir        // dirs that were linted successfully so far
+}
+
+// restricted returns true if only a subset of dirs or objects will have their
+// annotations reported.
+func (lc *lintContext) restricted() bool {
+	return lc.changedFiles != nil || lc.changedOnly
+}
+
+// dirChanges returns whether any file directly in dir is present in
+// lc.changedFiles, and whether any .skeema file in dir or its ancestors is
+// present in lc.changedFiles. A config file change potentially affects all
+// files in dir, even unchanged ones.
+func (lc *lintContext) dirChanges(dir *fs.Dir) (filesChanged, configChanged bool) {
+	for path := range lc.changedFiles {
+		parent := filepath.Dir(path)
+		if parent == dir.Path {
+			filesChanged = true
+		}
+		if filepath.Base(path) == ".skeema" && (parent == dir.Path || strings.HasPrefix(dir.Path, parent+string(filepath.Separator))) {
+			configChanged = true
+		}
+	}
+	return
+}
+
+// lintWalker lints dir, and then recursively lints its subdirs, applying any
+// restrictions or filtering specified in lc.
+func lintWalker(dir *fs.Dir, lc *lintContext, maxDepth int) (result *linter.Result) {
+	var filterFiles bool
+	if lc.changedFiles != nil {
+		filesChanged, configChanged := lc.dirChanges(dir)
+		if !filesChanged && !configChanged {
+			log.Infof("Skipping %s: no changes since %s", dir, lc.changedSince)
+			result = &linter.Result{}
+		}
+		filterFiles = !configChanged
+	}
+	if result == nil {
+		log.Infof("Linting %s", dir)
+		result = lintDir(dir, lc, filterFiles)
+		if len(result.Exceptions) == 0 {
+			lc.lintedDirs = append(lc.lintedDirs, dir)
+		}
+	}
+
+	var subdirErr error
+	if subdirs, badCount, err := dir.Subdirs(); err != nil {
+		subdirErr = fmt.Errorf("Cannot list subdirs of %s: %s", dir, err)
+	} else if len(subdirs) > 0 && maxDepth <= 0 {
+		subdirErr = fmt.Errorf("Not walking subdirs of %s: max depth reached", dir)
+	} else {
+		if badCount > 0 {
+			subdirErr = fmt.Errorf("Ignoring %d subdirs of %s with configuration errors", badCount, dir)
+		}
+		for _, sub := range subdirs {
+			result.Merge(lintWalker(sub, lc, maxDepth-1))
+		}
+	}
+	if subdirErr != nil {
+		log.Error(subdirErr)
+		result.Exceptions = append(result.Exceptions, subdirErr)
+	}
+	return result
+}
+
+// lintDir lints dir without recursing into subdirs, and logs the results. If
+// filterFiles is true, only annotations in files listed in lc.changedFiles are
+// retained.
+func lintDir(dir *fs.Dir, lc *lintContext, filterFiles bool) (result *linter.Result) {
 	// Connect to first defined instance, unless configured to use local Docker
 	var inst *tengo.Instance
 	if wsType, _ := dir.Config.GetEnum("workspace", "temp-schema", "docker"); wsType != "docker" || !dir.Config.Changed("flavor") {
@@ -81,7 +266,25 @@ func lintWalker(dir *fs.Dir, maxDepth int) (result *linter.Result) {
 	}
 
 	if result == nil {
-		result = linter.LintDir(dir, opts)
+		result = lintDirOnce(dir, inst, opts, lc, filterFiles)
+		// With --fix, apply fixes and then lint again to confirm them, until no
+		// fixes remain. Only one fix per statement can be applied in each pass,
+		// since each fix replaces the entire statement. Format notices from earlier
+		// passes are retained if normalizing, since their files still need to be
+		// rewritten.
+		for pass := 0; lc.fix && pass < maxFixPasses && len(result.Exceptions) == 0; pass++ {
+			if applyFixes(result) == 0 || len(result.Exceptions) > 0 {
+				break
+			}
+			prevResult := result
+			result = lintDirOnce(dir, inst, opts, lc, filterFiles)
+			if dir.Config.GetBool("normalize") {
+				result.FormatNotices = append(prevResult.FormatNotices, result.FormatNotices...)
+			}
+		}
+	}
+	if lc.newBaseline != nil {
+		lc.newBaseline.Add(dir.Path, result)
 	}
 	for _, err := range result.Exceptions {
 		log.Error(fmt.Errorf("Skipping schema in %s due to error: %s", dir.RelPath(), err))
@@ -93,6 +296,10 @@ func lintWalker(dir *fs.Dir, maxDepth int) (result *linter.Result) {
 		log.Warning(annotation.MessageWithLocation())
 	}
 	for _, annotation := range result.FormatNotices {
+		if !dir.Config.GetBool("normalize") {
+			log.Warnf("%s: SQL statement is not in canonical format; run `skeema format` to fix", annotation.Statement.Location())
+			continue
+		}
 		length, err := annotation.Statement.FromFile.Rewrite()
 		if err != nil {
 			writeErr := fmt.Errorf("Unable to write to %s: %s", annotation.Statement.File, err)
@@ -105,23 +312,132 @@ func lintWalker(dir *fs.Dir, maxDepth int) (result *linter.Result) {
 	for _, dl := range result.DebugLogs {
 		log.Debug(dl)
 	}
+	return result
+}
 
-	var subdirErr error
-	if subdirs, badCount, err := dir.Subdirs(); err != nil {
-		subdirErr = fmt.Errorf("Cannot list subdirs of %s: %s", dir, err)
-	} else if len(subdirs) > 0 && maxDepth <= 0 {
-		subdirErr = fmt.Errorf("Not walking subdirs of %s: max depth reached", dir)
-	} else {
-		if badCount > 0 {
-			subdirErr = fmt.Errorf("Ignoring %d subdirs of %s with configuration errors", badCount, dir)
+// lintDirOnce performs a single pass of linting dir, returning the result
+// after applying any filtering specified in lc.
+func lintDirOnce(dir *fs.Dir, inst *tengo.Instance, opts workspace.Options, lc *lintContext, filterFiles bool) *linter.Result {
+	result := linter.LintDir(dir, opts)
+	// Protected tables and columns can only be checked against a live instance
+	if inst != nil && len(result.Exceptions) == 0 {
+		result.Merge(linter.CheckProtected(dir, inst, result.Schemas))
+	}
+	if lc.againstCfg != nil && len(result.Exceptions) == 0 {
+		result.Merge(lintPlan(dir, lc.againstCfg, lc.changedOnly, result))
+	}
+	if lc.targetFlavor.Known() && len(result.Exceptions) == 0 {
+		result.Merge(lintUpgrade(dir, opts, lc.targetFlavor, result.Schemas))
+	}
+	// Annotations without a file, such as for dropped tables, are never filtered
+	if filterFiles {
+		result.Filter(func(a *linter.Annotation) bool {
+			return a.Statement.File == "" || lc.changedFiles[a.Statement.File]
+		})
+	}
+	if lc.baseline != nil {
+		lc.baseline.Filter(dir.Path, result)
+	}
+	return result
+}
+
+// maxFixPasses is the maximum number of times lint --fix will apply fixes to
+// a single dir.
+const maxFixPasses = 5
+
+// applyFixes applies the fixes of result's errors and warnings, returning the
+// number of fixes applied. At most one fix is applied per statement. Any
+// failures to rewrite files are added to result.Exceptions.
+func applyFixes(result *linter.Result) (count int) {
+	fixed := make(map[*fs.Statement]bool)
+	for _, a := range append(result.Errors, result.Warnings...) {
+		if a.Fix == "" || fixed[a.Statement] {
+			continue
 		}
-		for _, sub := range subdirs {
-			result.Merge(lintWalker(sub, maxDepth-1))
+		if err := a.ApplyFix(); err != nil {
+			writeErr := fmt.Errorf("Unable to write to %s: %s", a.Statement.File, err)
+			log.Error(writeErr.Error())
+			result.Exceptions = append(result.Exceptions, writeErr)
+			continue
 		}
+		log.Infof("Wrote %s -- applied fix for %s in %s", a.Statement.File, a.Problem, a.Statement.ObjectKey())
+		fixed[a.Statement] = true
+		count++
 	}
-	if subdirErr != nil {
-		log.Error(subdirErr)
-		result.Exceptions = append(result.Exceptions, subdirErr)
+	return count
+}
+
+// lintCrossSchema checks the schemas of all dirs in lc.lintedDirs for
+// consistency with one another, and logs the results. schemas should be the
+// schemas obtained from linting those dirs.
+func lintCrossSchema(lc *lintContext, schemas map[string]*tengo.Schema) *linter.Result {
+	log.Info("Checking consistency between schemas")
+	result := linter.CheckConsistency(lc.lintedDirs, schemas)
+	if lc.changedFiles != nil {
+		result.Filter(func(a *linter.Annotation) bool {
+			return lc.changedFiles[a.Statement.File]
+		})
+	}
+	// Annotations without a file are attributed to the top-level dir, since
+	// they may involve several dirs
+	if lc.baseline != nil {
+		lc.baseline.Filter(lc.baseline.BaseDir, result)
+	}
+	if lc.newBaseline != nil {
+		lc.newBaseline.Add(lc.newBaseline.BaseDir, result)
+	}
+	for _, annotation := range result.Warnings {
+		log.Warning(annotation.MessageWithLocation())
 	}
 	return result
 }
+
+// lintUpgrade checks dir for compatibility with targetFlavor, by executing it
+// in a Docker workspace of that flavor. opts should be the workspace options
+// used for linting dir, and schemas should be the resulting schemas.
+func lintUpgrade(dir *fs.Dir, opts workspace.Options, targetFlavor tengo.Flavor, schemas map[string]*tengo.Schema) *linter.Result {
+	targetOpts, err := workspace.OptionsForFlavor(dir, targetFlavor)
+	if err != nil {
+		return linter.BadConfigResult(dir, err)
+	}
+	currentFlavor := opts.Flavor
+	if opts.Type == workspace.TypeTempSchema && opts.Instance != nil {
+		currentFlavor = opts.Instance.Flavor()
+	}
+	return linter.CheckUpgrade(dir, targetOpts, currentFlavor, schemas)
+}
+
+// lintPlan checks the ALTERs that would be generated by pushing dir to the
+// first instance of the environment that againstCfg refers to. dirResult should
+// be the result of linting dir. If changedOnly is true, dirResult is also
+// filtered in-place to only retain annotations for objects that would be
+// created or altered by the push.
+func lintPlan(dir *fs.Dir, againstCfg *mybase.Config, changedOnly bool, dirResult *linter.Result) *linter.Result {
+	targetDir, err := fs.ParseDir(dir.Path, againstCfg)
+	if err != nil {
+		return linter.BadConfigResult(dir, err)
+	}
+	if !targetDir.Config.Changed("host") || !targetDir.HasSchema() {
+		return nil
+	}
+	inst, err := targetDir.FirstInstance()
+	if err != nil {
+		return linter.BadConfigResult(dir, err)
+	} else if inst == nil {
+		return nil
+	}
+	schemaNames, err := targetDir.SchemaNames(inst)
+	if err != nil {
+		return &linter.Result{Exceptions: []error{err}}
+	}
+	if changedOnly {
+		changed, err := linter.ChangedObjects(dir, inst, schemaNames, dirResult.Schemas)
+		if err != nil {
+			return &linter.Result{Exceptions: []error{err}}
+		}
+		dirResult.Filter(func(a *linter.Annotation) bool {
+			return a.Statement.File == "" || changed[a.Statement.ObjectKey()]
+		})
+	}
+	return linter.CheckPlan(dir, inst, schemaNames, dirResult.Schemas)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+)
+
+func TestLintContextDirChanges(t *testing.T) {
+	lc := &lintContext{
+		changedFiles: map[string]bool{
+			"/repo/mydb/a/foo.sql":    true,
+			"/repo/mydb/b/.skeema":    true,
+			"/repo/mydb/deleted.sql":  true,
+			"/repo/mydbx/.skeema":     true,
+			"/repo/other/notes/x.txt": true,
+		},
+	}
+	cases := []struct {
+		path          string
+		filesChanged  bool
+		configChanged bool
+	}{
+		{"/repo", false, false},
+		{"/repo/mydb", true, false},
+		{"/repo/mydb/a", true, false},
+		{"/repo/mydb/b", true, true},
+		{"/repo/mydb/b/sub", false, true},
+		{"/repo/mydb/c", false, false},
+		{"/repo/other", false, false},
+	}
+	for _, c := range cases {
+		filesChanged, configChanged := lc.dirChanges(&fs.Dir{Path: c.path})
+		if filesChanged != c.filesChanged || configChanged != c.configChanged {
+			t.Errorf("Unexpected return from dirChanges(%s): expected %t,%t, found %t,%t", c.path, c.filesChanged, c.configChanged, filesChanged, configChanged)
+		}
+	}
+}
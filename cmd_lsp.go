@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/linter"
+	"github.com/skeema/skeema/lsp"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Run a language server for *.sql files over stdio"
+	desc := `Runs a Language Server Protocol server, communicating with an editor over
+STDIN and STDOUT. This command is intended to be launched by an editor or LSP
+client plugin, rather than run directly.
+
+Whenever a *.sql file is opened or saved, the directory containing it is linted
+using the same options as ` + "`" + `skeema lint` + "`" + `, and the resulting annotations are
+published as diagnostics. Files are never rewritten automatically; instead,
+statements that are not in canonical format, or that have a mechanical fix for
+a linter problem, are offered as code actions. Go-to-definition on the table
+name in a foreign key's REFERENCES clause jumps to that table's CREATE TABLE
+statement, which may be in another file or another schema's directory.
+
+This command relies on accessing database instances to test the SQL DDL. All DDL
+will be run against a temporary schema, with no impact on the real schema.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for obtaining a database instance
+to test the SQL DDL against. If no environment name is supplied, the default is
+"production".
+
+An exit code of 0 will be returned if the editor requested a shutdown before
+exiting, or 2+ otherwise.`
+
+	cmd := mybase.NewCommand("lsp", summary, desc, LSPHandler)
+	linter.AddCommandOptions(cmd)
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// LSPHandler is the handler method for `skeema lsp`
+func LSPHandler(cfg *mybase.Config) error {
+	// Diagnostics must refer to the files' current contents, so statements are
+	// never normalized; canonical formatting is offered as a code action instead
+	cfg.CLI.OptionValues["normalize"] = "0"
+
+	parseDir := func(dirPath string) (*fs.Dir, error) {
+		return fs.ParseDir(dirPath, cfg)
+	}
+	server := lsp.NewServer(os.Stdin, os.Stdout, parseDir, lspLintDir)
+	if err := server.Run(); err != nil {
+		return NewExitValue(CodeFatalError, err.Error())
+	}
+	return nil
+}
+
+// lspLintDir lints dir without recursing into subdirs. Unlike lintDir, it does
+// not log or rewrite anything.
+func lspLintDir(dir *fs.Dir) *linter.Result {
+	// Connect to first defined instance, unless configured to use local Docker
+	var inst *tengo.Instance
+	if wsType, _ := dir.Config.GetEnum("workspace", "temp-schema", "docker"); wsType != "docker" || !dir.Config.Changed("flavor") {
+		var err error
+		if inst, err = dir.FirstInstance(); err != nil {
+			return linter.BadConfigResult(dir, err)
+		}
+	}
+	opts, err := workspace.OptionsForDir(dir, inst)
+	if err != nil {
+		return linter.BadConfigResult(dir, err)
+	}
+	result := linter.LintDir(dir, opts)
+	// Protected tables and columns can only be checked against a live instance
+	if inst != nil && len(result.Exceptions) == 0 {
+		result.Merge(linter.CheckProtected(dir, inst, result.Schemas))
+	}
+	return result
+}
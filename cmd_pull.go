@@ -9,6 +9,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/skeema/workspace"
 	"github.com/skeema/tengo"
 )
@@ -137,7 +138,7 @@ func pullWalker(dir *fs.Dir, maxDepth int) (handledSchemaNames []string, skipCou
 func pullSchemaDir(dir *fs.Dir, instance *tengo.Instance, instSchema *tengo.Schema, logicalSchema *fs.LogicalSchema) error {
 	log.Infof("Updating %s to reflect %s %s", dir, instance, instSchema.Name)
 
-	ignoreTable, err := dir.Config.GetRegexp("ignore-table")
+	ignoreTable, err := util.IgnoreTableRegexp(dir.Config)
 	if err != nil {
 		return NewExitValue(CodeBadConfig, err.Error())
 	}
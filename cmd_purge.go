@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+func init() {
+	summary := "Drop tables that were previously soft-dropped by push"
+	desc := `Drops tables that were previously renamed, rather than dropped, by
+` + "`" + `skeema push --soft-drop` + "`" + `. Only soft-dropped tables that were renamed at least
+--retention-days ago are dropped; more recent ones are left in place, so that
+they may still be recovered by renaming them back to their original name.
+
+You may optionally pass an environment name as a CLI option. This will affect
+which section of .skeema config files is used for processing. For example,
+running ` + "`" + `skeema purge staging` + "`" + ` will apply config directives from the
+[staging] section of config files, as well as any sectionless directives at the
+top of the file. If no environment name is supplied, the default is
+"production".`
+
+	cmd := mybase.NewCommand("purge", summary, desc, PurgeHandler)
+	cmd.AddOption(mybase.StringOption("retention-days", 0, "7", "Only drop tables that were soft-dropped at least this many days ago"))
+	cmd.AddOption(mybase.BoolOption("dry-run", 0, false, "Output DROP statements but don't run them"))
+	cmd.AddArg("environment", "production", false)
+	CommandSuite.AddSubCommand(cmd)
+}
+
+// PurgeHandler is the handler method for `skeema purge`
+func PurgeHandler(cfg *mybase.Config) error {
+	dir, err := fs.ParseDir(".", cfg)
+	if err != nil {
+		return err
+	}
+
+	retentionDays, err := dir.Config.GetInt("retention-days")
+	if err == nil && retentionDays < 0 {
+		err = fmt.Errorf("retention-days cannot be less than 0")
+	}
+	if err != nil {
+		return NewExitValue(CodeBadConfig, err.Error())
+	}
+	cutoff := time.Now().AddDate(0, 0, -1*retentionDays)
+
+	if skipCount := purgeWalker(dir, cutoff, 5); skipCount > 0 {
+		var plural string
+		if skipCount > 1 {
+			plural = "s"
+		}
+		return NewExitValue(CodeFatalError, "Skipped %d operation%s due to error%s", skipCount, plural, plural)
+	}
+	return nil
+}
+
+// purgeWalker drops soft-dropped tables older than cutoff in all schemas that
+// dir maps to, and then recursively does the same for dir's subdirectories.
+// It returns a count of operations that were skipped due to errors.
+func purgeWalker(dir *fs.Dir, cutoff time.Time, maxDepth int) (skipCount int) {
+	if dir.Config.Changed("host") && dir.HasSchema() {
+		instances, err := dir.Instances()
+		if err != nil {
+			log.Warnf("Skipping %s: %s", dir, err)
+			skipCount++
+		}
+		for _, inst := range instances {
+			schemaNames, err := dir.SchemaNames(inst)
+			if err != nil {
+				log.Warnf("Skipping %s for %s: %s", inst, dir, err)
+				skipCount++
+				continue
+			}
+			for _, schemaName := range schemaNames {
+				if err := purgeSchema(dir, inst, schemaName, cutoff); err != nil {
+					log.Errorf("Error purging soft-dropped tables on %s %s: %s", inst, schemaName, err)
+					skipCount++
+				}
+			}
+		}
+	}
+
+	subdirs, badCount, err := dir.Subdirs()
+	skipCount += badCount
+	if err != nil {
+		log.Warnf("Skipping subdirs of %s: %s", dir, err)
+		return skipCount + 1
+	} else if len(subdirs) > 0 && maxDepth < 1 {
+		log.Warnf("Skipping subdirs of %s: max depth reached", dir)
+		return skipCount + len(subdirs)
+	}
+	for _, sub := range subdirs {
+		skipCount += purgeWalker(sub, cutoff, maxDepth-1)
+	}
+	return skipCount
+}
+
+// purgeSchema drops all soft-dropped tables in the specified schema that were
+// renamed before cutoff.
+func purgeSchema(dir *fs.Dir, inst *tengo.Instance, schemaName string, cutoff time.Time) error {
+	db, err := inst.Connect(schemaName, "")
+	if err != nil {
+		return err
+	}
+	var tableNames []string
+	query := `
+		SELECT table_name
+		FROM   information_schema.tables
+		WHERE  table_schema = ? AND table_name LIKE ?`
+	likePattern := fmt.Sprintf("%s%%", strings.Replace(util.TrashTablePrefix, "_", `\_`, -1))
+	if err := db.Select(&tableNames, query, schemaName, likePattern); err != nil {
+		return err
+	}
+
+	expired := expiredTrashTables(tableNames, cutoff)
+	for _, name := range expired {
+		droppedAt, _ := util.ParseTrashTableName(name)
+		stmt := fmt.Sprintf("DROP TABLE %s", tengo.EscapeIdentifier(name))
+		if dir.Config.GetBool("dry-run") {
+			fmt.Printf("-- instance: %s\nUSE %s;\n%s", inst, tengo.EscapeIdentifier(schemaName), fs.AddDelimiter(stmt))
+		} else {
+			if _, err := db.Exec(stmt); err != nil {
+				return err
+			}
+			log.Infof("%s %s: Dropped %s (soft-dropped on %s)", inst, schemaName, tengo.EscapeIdentifier(name), droppedAt.Format("2006-01-02 15:04:05"))
+		}
+	}
+	if len(expired) == 0 {
+		log.Infof("%s %s: No soft-dropped tables to purge", inst, schemaName)
+	}
+	return nil
+}
+
+// expiredTrashTables returns the subset of tableNames that are soft-dropped
+// tables which were renamed at or before cutoff.
+func expiredTrashTables(tableNames []string, cutoff time.Time) (expired []string) {
+	for _, name := range tableNames {
+		if droppedAt, ok := util.ParseTrashTableName(name); ok && !droppedAt.After(cutoff) {
+			expired = append(expired, name)
+		}
+	}
+	return expired
+}
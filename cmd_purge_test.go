@@ -0,0 +1,31 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/skeema/skeema/util"
+)
+
+func TestExpiredTrashTables(t *testing.T) {
+	now := time.Date(2019, time.March, 12, 9, 0, 0, 0, time.Local)
+	cutoff := now.AddDate(0, 0, -7)
+	tableNames := []string{
+		util.TrashTableName("old", cutoff.Add(-time.Hour)),
+		util.TrashTableName("exact", cutoff),
+		util.TrashTableName("recent", cutoff.Add(time.Hour)),
+		util.TrashTableName("today", now),
+		"_skeema_trash_20190304_legacy_old",
+		"_skeema_trash_20190306_legacy_recent",
+		"widgets",
+		"_skeema_trash_widgets",
+	}
+	expected := []string{tableNames[0], tableNames[1], tableNames[4]}
+	if actual := expiredTrashTables(tableNames, cutoff); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected expiredTrashTables to return %v, instead found %v", expected, actual)
+	}
+	if actual := expiredTrashTables(tableNames, now.AddDate(-1, 0, 0)); len(actual) != 0 {
+		t.Errorf("Expected no expired tables with cutoff a year ago, instead found %v", actual)
+	}
+}
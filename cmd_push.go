@@ -3,10 +3,12 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/applier"
 	"github.com/skeema/skeema/fs"
+	"golang.org/x/crypto/ssh/terminal"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -39,6 +41,11 @@ top of the file. If no environment name is supplied, the default is
 	cmd.AddOption(mybase.StringOption("alter-algorithm", 0, "", `Apply an ALGORITHM clause to all ALTER TABLEs (valid values: "INPLACE", "COPY", "INSTANT")`))
 	cmd.AddOption(mybase.StringOption("ddl-wrapper", 'X', "", "Like --alter-wrapper, but applies to all DDL types (CREATE, DROP, ALTER)"))
 	cmd.AddOption(mybase.StringOption("safe-below-size", 0, "0", "Always permit destructive operations for tables below this size in bytes"))
+	cmd.AddOption(mybase.BoolOption("soft-drop", 0, false, "Rename tables to a trash name instead of dropping them; see `skeema purge`"))
+	cmd.AddOption(mybase.StringOption("backup-dir", 0, "", "Save data from dropped tables and columns to files in this dir before running DDL"))
+	cmd.AddOption(mybase.StringOption("backup-format", 0, "CSV", `Format for files written by --backup-dir (valid values: "CSV", "SQL")`))
+	cmd.AddOption(mybase.BoolOption("verify-grants", 0, true, "Confirm the user has all privileges required by a schema's DDL before running any of it"))
+	cmd.AddOption(mybase.BoolOption("interactive", 0, false, "Prompt for confirmation before running each DDL statement"))
 	cmd.AddOption(mybase.StringOption("concurrent-instances", 'c', "1", "Perform operations on this number of instances concurrently"))
 	cmd.AddArg("environment", "production", false)
 	CommandSuite.AddSubCommand(cmd)
@@ -52,6 +59,10 @@ func PushHandler(cfg *mybase.Config) error {
 		return err
 	}
 
+	if dir.Config.GetBool("interactive") && !dir.Config.GetBool("dry-run") && !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return NewExitValue(CodeBadConfig, "STDIN must be a TTY to use --interactive")
+	}
+
 	briefMode := dir.Config.GetBool("dry-run") && dir.Config.GetBool("brief")
 	printer := applier.NewPrinter(briefMode)
 	g, ctx := errgroup.WithContext(context.Background())
@@ -0,0 +1,167 @@
+package linter
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BaselineEntry represents a single previously-existing annotation that should
+// not cause subsequent lint runs to fail.
+type BaselineEntry struct {
+	Problem     string `json:"problem"`
+	Dir         string `json:"dir"`
+	Object      string `json:"object"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// String returns a human-readable description of the entry.
+func (entry BaselineEntry) String() string {
+	dir := entry.Dir
+	if dir == "." {
+		dir = "top-level dir"
+	}
+	return fmt.Sprintf("%s for %s in %s", entry.Problem, entry.Object, dir)
+}
+
+// Baseline is a set of annotations that were present at the time the baseline
+// was written. Annotations matching an entry in the baseline are excluded from
+// lint results.
+type Baseline struct {
+	BaseDir string          `json:"-"`
+	Entries []BaselineEntry `json:"entries"`
+	used    []bool
+}
+
+// NewBaseline returns an empty baseline, to be populated via Add. Entry
+// directories are stored relative to baseDir.
+func NewBaseline(baseDir string) *Baseline {
+	return &Baseline{BaseDir: baseDir, Entries: []BaselineEntry{}}
+}
+
+// Add adds an entry for each error and warning in result that relates to a
+// named problem. Annotations not tied to a problem, such as invalid SQL, are
+// never included in a baseline. dirPath should be the dir that was linted to
+// obtain result; it is used as the location of any annotations that are not
+// tied to a file.
+func (b *Baseline) Add(dirPath string, result *Result) {
+	for _, list := range [][]*Annotation{result.Errors, result.Warnings} {
+		for _, a := range list {
+			if a.Problem != "" {
+				b.Entries = append(b.Entries, b.entryFor(a, dirPath))
+			}
+		}
+	}
+}
+
+// ReadBaseline reads a baseline file previously written by Baseline.Write.
+// Entry directories are interpreted relative to baseDir.
+func ReadBaseline(filePath, baseDir string) (*Baseline, error) {
+	contents, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	b := &Baseline{BaseDir: baseDir}
+	if err := json.Unmarshal(contents, b); err != nil {
+		return nil, fmt.Errorf("Unable to parse baseline file %s: %s", filePath, err)
+	}
+	b.used = make([]bool, len(b.Entries))
+	return b, nil
+}
+
+// Write writes the baseline to filePath in JSON format, overwriting any
+// existing file. Entries are sorted first.
+func (b *Baseline) Write(filePath string) error {
+	sort.Slice(b.Entries, func(i, j int) bool {
+		ei, ej := b.Entries[i], b.Entries[j]
+		if ei.Dir != ej.Dir {
+			return ei.Dir < ej.Dir
+		} else if ei.Object != ej.Object {
+			return ei.Object < ej.Object
+		} else if ei.Problem != ej.Problem {
+			return ei.Problem < ej.Problem
+		}
+		return ei.Fingerprint < ej.Fingerprint
+	})
+	contents, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filePath, append(contents, '\n'), 0666)
+}
+
+// Filter removes any errors and warnings from result that match an entry in
+// the baseline. Each baseline entry can match at most one annotation. dirPath
+// is used in the same manner as in Add.
+func (b *Baseline) Filter(dirPath string, result *Result) {
+	filter := func(list []*Annotation) (kept []*Annotation) {
+		for _, a := range list {
+			if a.Problem == "" || !b.match(a, dirPath) {
+				kept = append(kept, a)
+			} else {
+				result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping %s for %s because it is present in baseline", a.Problem, a.Statement.ObjectKey()))
+			}
+		}
+		return kept
+	}
+	result.Errors = filter(result.Errors)
+	result.Warnings = filter(result.Warnings)
+}
+
+// Fixed returns the entries of the baseline which have not matched any
+// annotation in prior calls to Filter. These typically correspond to problems
+// that have since been fixed.
+func (b *Baseline) Fixed() (fixed []BaselineEntry) {
+	for n, entry := range b.Entries {
+		if !b.used[n] {
+			fixed = append(fixed, entry)
+		}
+	}
+	return fixed
+}
+
+// match returns true if a matches a not-yet-used baseline entry, in which case
+// the entry is marked as used.
+func (b *Baseline) match(a *Annotation, dirPath string) bool {
+	if b.used == nil {
+		b.used = make([]bool, len(b.Entries))
+	}
+	target := b.entryFor(a, dirPath)
+	for n, entry := range b.Entries {
+		if entry == target && !b.used[n] {
+			b.used[n] = true
+			return true
+		}
+	}
+	return false
+}
+
+// entryFor returns a baseline entry corresponding to a. The fingerprint is
+// based on the problem name and the text of the offending line, with
+// whitespace normalized, so that entries remain valid even if the statement
+// moves within its file or the annotation message changes. If a has no file,
+// dirPath is used as its location.
+func (b *Baseline) entryFor(a *Annotation, dirPath string) BaselineEntry {
+	dir := dirPath
+	if a.Statement.File != "" {
+		dir = filepath.Dir(a.Statement.File)
+	}
+	if rel, err := filepath.Rel(b.BaseDir, dir); err == nil {
+		dir = rel
+	}
+	var line string
+	if lines := strings.Split(a.Statement.Text, "\n"); a.LineOffset >= 0 && a.LineOffset < len(lines) {
+		line = strings.Join(strings.Fields(lines[a.LineOffset]), " ")
+	}
+	sum := sha1.Sum([]byte(a.Problem + "\n" + line))
+	return BaselineEntry{
+		Problem:     a.Problem,
+		Dir:         filepath.ToSlash(dir),
+		Object:      a.Statement.ObjectKey().String(),
+		Fingerprint: fmt.Sprintf("%x", sum),
+	}
+}
@@ -0,0 +1,113 @@
+package linter
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestBaseline(t *testing.T) {
+	makeStmt := func(file, name, text string) *fs.Statement {
+		return &fs.Statement{
+			File:       file,
+			Text:       text,
+			Type:       fs.StatementTypeCreate,
+			ObjectType: tengo.ObjectTypeTable,
+			ObjectName: name,
+		}
+	}
+	foo := makeStmt("/base/foo.sql", "foo", "CREATE TABLE foo (\n  a float,\n  b float\n) ENGINE=MyISAM;\n")
+	bar := makeStmt("/base/sub/bar.sql", "bar", "CREATE TABLE bar (\n  a float\n);\n")
+	result := &Result{
+		Errors: []*Annotation{
+			{Statement: foo, LineOffset: 1, Problem: "has-float"},
+			{Statement: foo, LineOffset: 2, Problem: "has-float"},
+			{Statement: foo, Summary: "SQL statement returned an error"},
+		},
+		Warnings: []*Annotation{
+			{Statement: foo, LineOffset: 3, Problem: "bad-engine"},
+			{Statement: bar, LineOffset: 1, Problem: "has-float"},
+		},
+	}
+	// Annotations without a file are located in the dir passed to Add
+	dropped := &fs.Statement{Text: "DROP TABLE baz", ObjectType: tengo.ObjectTypeTable, ObjectName: "baz"}
+	subResult := &Result{
+		Warnings: []*Annotation{{Statement: dropped, Problem: "plan-pk-change"}},
+	}
+	baseline := NewBaseline("/base")
+	baseline.Add("/base", result)
+	baseline.Add("/base/sub", subResult)
+	filePath := "baseline-test.json"
+	if err := baseline.Write(filePath); err != nil {
+		t.Fatalf("Unexpected error from Write: %s", err)
+	}
+	if len(baseline.Entries) != 5 {
+		t.Fatalf("Expected baseline to have 5 entries, instead found %d", len(baseline.Entries))
+	}
+	if baseline.Entries[0].Dir != "." || baseline.Entries[3].Dir != "sub" || baseline.Entries[3].Object != "table `bar`" || baseline.Entries[4].Dir != "sub" || baseline.Entries[4].Object != "table `baz`" {
+		t.Errorf("Unexpected baseline entries: %+v", baseline.Entries)
+	}
+	defer os.Remove(filePath)
+	baseline, err := ReadBaseline(filePath, "/base")
+	if err != nil {
+		t.Fatalf("Unexpected error from ReadBaseline: %s", err)
+	}
+
+	// Reformat foo and fix one of its float columns; move bar to a different
+	// line of its file; add a new problem. Only the new problem and the SQL
+	// error should remain after filtering, and the fixed float column should be
+	// reported by Fixed.
+	origFoo := foo
+	foo = makeStmt("/base/foo.sql", "foo", "CREATE TABLE foo (\n    a   float,\n  b int\n) ENGINE=MyISAM;\n")
+	bar.LineNo = 20
+	result = &Result{
+		Errors: []*Annotation{
+			{Statement: foo, LineOffset: 1, Problem: "has-float"},
+			{Statement: foo, Summary: "SQL statement returned an error"},
+			{Statement: foo, Problem: "no-pk"},
+		},
+		Warnings: []*Annotation{
+			{Statement: foo, LineOffset: 3, Problem: "bad-engine"},
+			{Statement: bar, LineOffset: 1, Problem: "has-float"},
+		},
+	}
+	baseline.Filter("/base", result)
+	if len(result.Errors) != 2 || result.Errors[0].Problem != "" || result.Errors[1].Problem != "no-pk" {
+		t.Errorf("Unexpected errors after filtering: %+v", result.Errors)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("Unexpected warnings after filtering: %+v", result.Warnings)
+	}
+	expectFixed := []BaselineEntry{{
+		Problem:     "has-float",
+		Dir:         ".",
+		Object:      "table `foo`",
+		Fingerprint: baseline.entryFor(&Annotation{Statement: origFoo, Problem: "has-float", LineOffset: 2}, "/base").Fingerprint,
+	}, {
+		Problem:     "plan-pk-change",
+		Dir:         "sub",
+		Object:      "table `baz`",
+		Fingerprint: baseline.entryFor(subResult.Warnings[0], "/base/sub").Fingerprint,
+	}}
+	if fixed := baseline.Fixed(); !reflect.DeepEqual(fixed, expectFixed) {
+		t.Errorf("Unexpected result from Fixed: %+v", fixed)
+	}
+
+	// A file-less annotation only matches when filtered for the same dir
+	subResult.Warnings = []*Annotation{{Statement: dropped, Problem: "plan-pk-change"}}
+	baseline.Filter("/base", subResult)
+	if len(subResult.Warnings) != 1 {
+		t.Errorf("Expected file-less annotation from a different dir to remain after filtering, instead found %d warnings", len(subResult.Warnings))
+	}
+	baseline.Filter("/base/sub", subResult)
+	if len(subResult.Warnings) != 0 || len(baseline.Fixed()) != 1 {
+		t.Errorf("Expected file-less annotation to be filtered, instead found %d warnings", len(subResult.Warnings))
+	}
+
+	if _, err := ReadBaseline("does-not-exist.json", "/base"); err == nil {
+		t.Error("Expected error from ReadBaseline on nonexistent file, but err was nil")
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/skeema/mybase"
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/tengo"
 )
 
@@ -17,6 +18,7 @@ type Severity string
 const (
 	SeverityError   Severity = "error"
 	SeverityWarning Severity = "warning"
+	SeverityIgnore  Severity = "ignore" // only used in per-object overrides
 )
 
 // AddCommandOptions adds linting-related mybase options to the supplied
@@ -26,15 +28,88 @@ func AddCommandOptions(cmd *mybase.Command) {
 	cmd.AddOption(mybase.StringOption("errors", 0, "", "Linter problems to treat as fatal errors; see manual for usage"))
 	cmd.AddOption(mybase.StringOption("allow-charset", 0, "latin1,utf8mb4", "Whitelist of acceptable character sets"))
 	cmd.AddOption(mybase.StringOption("allow-engine", 0, "innodb", "Whitelist of acceptable storage engines"))
+	for _, objectType := range namingObjectTypes {
+		desc := fmt.Sprintf("Regular expression that all %s names must match", strings.Replace(objectType, "-", " ", -1))
+		cmd.AddOption(mybase.StringOption("naming-"+objectType, 0, "", desc))
+	}
+	cmd.AddOption(mybase.StringOption("naming-disallow", 0, "reserved-word,mixed-case", `Additional name properties flagged by --warnings=bad-name or --errors=bad-name (valid values: "reserved-word", "mixed-case")`))
+	cmd.AddOption(mybase.StringOption("allow-float", 0, "", "Regular expression of column names permitted to use FLOAT or DOUBLE with --warnings=has-float or --errors=has-float"))
+	cmd.AddOption(mybase.StringOption("allow-enum", 0, "", "Regular expression of column names permitted to use ENUM or SET with --warnings=has-enum or --errors=has-enum"))
+	cmd.AddOption(mybase.StringOption("allow-timestamp", 0, "", "Regular expression of column names permitted to use TIMESTAMP with --warnings=has-timestamp or --errors=has-timestamp"))
+	cmd.AddOption(mybase.StringOption("allow-pk-type", 0, "bigint", "Whitelist of acceptable column types for auto-increment primary keys"))
+	cmd.AddOption(mybase.StringOption("allow-definer", 0, "%@%", "Whitelist of acceptable routine definers; % may be used as a wildcard"))
+	cmd.AddOption(mybase.StringOption("required-sql-mode", 0, "", "sql_mode that all routines must have been created with"))
+	cmd.AddOption(mybase.StringOption("require-comment", 0, "table,column", `Object types that must have a comment with --warnings=missing-comment or --errors=missing-comment (valid values: "table", "column", "index")`))
+	cmd.AddOption(mybase.StringOption("require-comment-min-length", 0, "1", "Minimum length of comments required by option require-comment"))
+	cmd.AddOption(mybase.StringOption("require-comment-exempt", 0, "primary-key,timestamp", `Columns exempt from option require-comment (valid values: "primary-key", "timestamp")`))
+	cmd.AddOption(mybase.StringOption("max-columns", 0, "100", "Maximum number of columns per table with --warnings=too-many-columns or --errors=too-many-columns"))
+	cmd.AddOption(mybase.StringOption("max-indexes", 0, "16", "Maximum number of indexes per table with --warnings=too-many-indexes or --errors=too-many-indexes"))
+	cmd.AddOption(mybase.StringOption("max-index-key-length", 0, "", "Maximum index key length in bytes with --warnings=index-too-long or --errors=index-too-long; default is based on each table's storage engine and ROW_FORMAT"))
+	cmd.AddOption(mybase.StringOption("max-row-size", 0, "65535", "Maximum estimated row size in bytes with --warnings=row-too-large or --errors=row-too-large"))
+	cmd.AddOption(mybase.StringOption("max-varchar-length", 0, "1024", "Maximum VARCHAR length in characters with --warnings=varchar-too-long or --errors=varchar-too-long"))
+	cmd.AddOption(mybase.StringOption("large-table-size", 0, "1G", "With --against, tables at least this size are considered large when assessing ALTER risks"))
+	cmd.AddOption(mybase.BoolOption("normalize", 0, true, "Reformat SQL statements to match canonical SHOW CREATE"))
+	cmd.AddOption(mybase.StringOption("external-linter", 0, "", "Shell command to run as an additional linter; see manual for protocol"))
+	cmd.AddOption(mybase.StringOption("external-linter-severity", 0, "warning", `Severity of annotations from --external-linter (valid values: "warning", "error")`))
+
+	// Per-object severity overrides are hidden from help output, since there are
+	// several for each problem
+	for _, problem := range allProblemNames() {
+		for _, severity := range overrideSeverities {
+			desc := fmt.Sprintf("Regular expression of object names for which problem %s has severity %s", problem, severity)
+			cmd.AddOption(mybase.StringOption(fmt.Sprintf("lint-%s-%s", problem, severity), 0, "", desc).Hidden())
+		}
+	}
+}
+
+// overrideSeverities lists the severities that may be used in per-object
+// overrides, in order of precedence. Each has a corresponding
+// "lint-<problem>-<severity>" option for every problem.
+var overrideSeverities = []Severity{SeverityIgnore, SeverityError, SeverityWarning}
+
+// namingObjectTypes lists the types of identifiers that may have naming
+// conventions enforced via options. Each has a corresponding "naming-" option.
+var namingObjectTypes = []string{"table", "column", "index", "foreign-key", "procedure", "function"}
+
+// SeverityOverride changes the severity of a problem for objects whose names
+// match Pattern. With SeverityIgnore, the problem is not reported for those
+// objects at all.
+type SeverityOverride struct {
+	Pattern  *regexp.Regexp
+	Severity Severity
 }
 
 // Options contains parsed settings controlling linter behavior.
 type Options struct {
-	ProblemSeverity map[string]Severity
-	AllowedCharSets []string
-	AllowedEngines  []string
-	IgnoreSchema    *regexp.Regexp
-	IgnoreTable     *regexp.Regexp
+	ProblemSeverity   map[string]Severity
+	SeverityOverrides map[string][]SeverityOverride // keyed by problem name
+	AllowedCharSets   []string
+	AllowedEngines    []string
+	AllowedPKTypes    []string
+	AllowedDefiners   []string
+	AllowFloat        *regexp.Regexp // column names exempt from has-float
+	AllowEnum         *regexp.Regexp // column names exempt from has-enum
+	AllowTimestamp    *regexp.Regexp // column names exempt from has-timestamp
+	RequiredSQLMode   []string
+	RequiredComments  []string
+	CommentMinLength  int
+	CommentExempt     []string
+	MaxColumns        int
+	MaxIndexes        int
+	MaxIndexKeyLength uint64 // 0 means use the limit for each table's ROW_FORMAT
+	MaxRowSize        uint64
+	MaxVarcharLength  int
+	NamePatterns      map[string]*regexp.Regexp // keyed by value in namingObjectTypes
+	NamingDisallow    []string
+	Flavor            tengo.Flavor
+	ExternalLinter    *util.ShellOut
+	ExternalSeverity  Severity
+	Normalize         bool
+	LargeTableSize    uint64
+	IgnoreSchema      *regexp.Regexp
+	IgnoreTable       *regexp.Regexp
+	ProtectTable      *regexp.Regexp
+	ProtectColumn     *regexp.Regexp
 }
 
 // ShouldIgnore returns true if the option configuration indicates the supplied
@@ -48,16 +123,113 @@ func (opts Options) ShouldIgnore(key tengo.ObjectKey) bool {
 	return false
 }
 
+// SeverityFor returns the severity of problem for the object with the supplied
+// key, taking any per-object overrides into account. If the problem should not
+// be reported for the object, ok will be false.
+func (opts Options) SeverityFor(problem string, key tengo.ObjectKey) (severity Severity, ok bool) {
+	for _, override := range opts.SeverityOverrides[problem] {
+		if !override.Pattern.MatchString(key.Name) {
+			continue
+		} else if override.Severity == SeverityIgnore {
+			return "", false
+		}
+		return override.Severity, true
+	}
+	severity, ok = opts.ProblemSeverity[problem]
+	return severity, ok
+}
+
+// enabledProblems returns a sorted list of problems which are reported for at
+// least some objects, either via the warnings and errors options or via
+// per-object overrides.
+func (opts Options) enabledProblems() []string {
+	enabled := make([]string, 0, len(opts.ProblemSeverity))
+	for _, problem := range allProblemNames() {
+		if _, ok := opts.ProblemSeverity[problem]; ok {
+			enabled = append(enabled, problem)
+			continue
+		}
+		for _, override := range opts.SeverityOverrides[problem] {
+			if override.Severity != SeverityIgnore {
+				enabled = append(enabled, problem)
+				break
+			}
+		}
+	}
+	return enabled
+}
+
 // OptionsForDir returns Options based on the configuration in an fs.Dir,
 // effectively converting between mybase options and linter options.
 func OptionsForDir(dir *fs.Dir) (Options, error) {
 	opts := Options{
-		ProblemSeverity: make(map[string]Severity),
-		AllowedCharSets: dir.Config.GetSlice("allow-charset", ',', true),
-		AllowedEngines:  dir.Config.GetSlice("allow-engine", ',', true),
+		ProblemSeverity:   make(map[string]Severity),
+		SeverityOverrides: make(map[string][]SeverityOverride),
+		AllowedCharSets:   dir.Config.GetSlice("allow-charset", ',', true),
+		AllowedEngines:    dir.Config.GetSlice("allow-engine", ',', true),
+		AllowedPKTypes:    dir.Config.GetSlice("allow-pk-type", ',', true),
+		AllowedDefiners:   dir.Config.GetSlice("allow-definer", ',', true),
+		RequiredSQLMode:   dir.Config.GetSlice("required-sql-mode", ',', true),
+		RequiredComments:  dir.Config.GetSlice("require-comment", ',', true),
+		CommentExempt:     dir.Config.GetSlice("require-comment-exempt", ',', true),
+		NamePatterns:      make(map[string]*regexp.Regexp),
+		NamingDisallow:    dir.Config.GetSlice("naming-disallow", ',', true),
+		Flavor:            tengo.NewFlavor(dir.Config.Get("flavor")),
+		Normalize:         dir.Config.GetBool("normalize"),
 	}
 
 	var err error
+	opts.LargeTableSize, err = dir.Config.GetBytes("large-table-size")
+	if err != nil {
+		return Options{}, toConfigError(dir, err)
+	}
+	for _, val := range opts.RequiredComments {
+		if !isAllowed(val, []string{"table", "column", "index"}) {
+			return Options{}, newConfigError(dir, "Option require-comment must be a comma-separated list including these values: table, column, index")
+		}
+	}
+	for _, val := range opts.NamingDisallow {
+		if !isAllowed(val, []string{"reserved-word", "mixed-case"}) {
+			return Options{}, newConfigError(dir, "Option naming-disallow must be a comma-separated list including these values: reserved-word, mixed-case")
+		}
+	}
+	for _, val := range opts.CommentExempt {
+		if !isAllowed(val, []string{"primary-key", "timestamp"}) {
+			return Options{}, newConfigError(dir, "Option require-comment-exempt must be a comma-separated list including these values: primary-key, timestamp")
+		}
+	}
+	opts.CommentMinLength, err = dir.Config.GetInt("require-comment-min-length")
+	if err != nil {
+		return Options{}, toConfigError(dir, err)
+	} else if opts.CommentMinLength < 1 {
+		return Options{}, newConfigError(dir, "Option require-comment-min-length must be at least 1")
+	}
+	for optionName, dest := range map[string]*int{"max-columns": &opts.MaxColumns, "max-indexes": &opts.MaxIndexes, "max-varchar-length": &opts.MaxVarcharLength} {
+		if *dest, err = dir.Config.GetInt(optionName); err != nil {
+			return Options{}, toConfigError(dir, err)
+		} else if *dest < 1 {
+			return Options{}, newConfigError(dir, "Option %s must be at least 1", optionName)
+		}
+	}
+	if opts.MaxRowSize, err = dir.Config.GetBytes("max-row-size"); err != nil {
+		return Options{}, toConfigError(dir, err)
+	} else if opts.MaxRowSize < 1 {
+		return Options{}, newConfigError(dir, "Option max-row-size must be at least 1")
+	}
+	// max-index-key-length is only an override; by default the limit is based
+	// on each table's ROW_FORMAT
+	if dir.Config.Get("max-index-key-length") != "" {
+		if opts.MaxIndexKeyLength, err = dir.Config.GetBytes("max-index-key-length"); err != nil {
+			return Options{}, toConfigError(dir, err)
+		} else if opts.MaxIndexKeyLength < 1 {
+			return Options{}, newConfigError(dir, "Option max-index-key-length must be at least 1")
+		}
+	}
+	for optionName, dest := range map[string]**regexp.Regexp{"allow-float": &opts.AllowFloat, "allow-enum": &opts.AllowEnum, "allow-timestamp": &opts.AllowTimestamp} {
+		if *dest, err = dir.Config.GetRegexp(optionName); err != nil {
+			return Options{}, toConfigError(dir, err)
+		}
+	}
 	opts.IgnoreSchema, err = dir.Config.GetRegexp("ignore-schema")
 	if err != nil {
 		return Options{}, toConfigError(dir, err)
@@ -66,6 +238,40 @@ func OptionsForDir(dir *fs.Dir) (Options, error) {
 	if err != nil {
 		return Options{}, toConfigError(dir, err)
 	}
+	opts.ProtectTable, err = dir.Config.GetRegexp("protect-table")
+	if err != nil {
+		return Options{}, toConfigError(dir, err)
+	}
+	opts.ProtectColumn, err = dir.Config.GetRegexp("protect-column")
+	if err != nil {
+		return Options{}, toConfigError(dir, err)
+	}
+
+	if command := dir.Config.Get("external-linter"); command != "" {
+		variables := map[string]string{
+			"ENVIRONMENT": dir.Config.Get("environment"),
+			"DIRNAME":     dir.BaseName(),
+			"DIRPATH":     dir.Path,
+		}
+		if opts.ExternalLinter, err = util.NewInterpolatedShellOut(command, variables); err != nil {
+			return Options{}, toConfigError(dir, err)
+		}
+		opts.ExternalLinter.Dir = dir.Path
+		severity, err := dir.Config.GetEnum("external-linter-severity", string(SeverityWarning), string(SeverityError))
+		if err != nil {
+			return Options{}, toConfigError(dir, err)
+		}
+		opts.ExternalSeverity = Severity(severity)
+	}
+
+	for _, objectType := range namingObjectTypes {
+		re, err := dir.Config.GetRegexp("naming-" + objectType)
+		if err != nil {
+			return Options{}, toConfigError(dir, err)
+		} else if re != nil {
+			opts.NamePatterns[objectType] = re
+		}
+	}
 
 	// Populate opts.ProblemSeverity from the warnings and errors options (in
 	// that order, so that in case of duplicate entries, errors take precedence).
@@ -86,21 +292,62 @@ func OptionsForDir(dir *fs.Dir) (Options, error) {
 		opts.ProblemSeverity[val] = SeverityError
 	}
 
+	// Populate opts.SeverityOverrides from lint-<problem>-<severity> options.
+	// Problems registered after the options were added cannot be overridden.
+	for _, problem := range allProblemNames() {
+		for _, severity := range overrideSeverities {
+			optionName := fmt.Sprintf("lint-%s-%s", problem, severity)
+			if dir.Config.FindOption(optionName) == nil {
+				continue
+			}
+			re, err := dir.Config.GetRegexp(optionName)
+			if err != nil {
+				return Options{}, toConfigError(dir, err)
+			} else if re != nil {
+				opts.SeverityOverrides[problem] = append(opts.SeverityOverrides[problem], SeverityOverride{Pattern: re, Severity: severity})
+			}
+		}
+	}
+
 	// For list-based problems, confirm corresponding list is non-empty
 	problemToList := map[string][]string{
-		"bad-charset": opts.AllowedCharSets,
-		"bad-engine":  opts.AllowedEngines,
+		"bad-charset":     opts.AllowedCharSets,
+		"bad-engine":      opts.AllowedEngines,
+		"pk-type":         opts.AllowedPKTypes,
+		"bad-definer":     opts.AllowedDefiners,
+		"bad-sql-mode":    opts.RequiredSQLMode,
+		"missing-comment": opts.RequiredComments,
+	}
+	listOptionNames := map[string]string{
+		"bad-charset":     "allow-charset",
+		"bad-engine":      "allow-engine",
+		"pk-type":         "allow-pk-type",
+		"bad-definer":     "allow-definer",
+		"bad-sql-mode":    "required-sql-mode",
+		"missing-comment": "require-comment",
 	}
 	for problem, listOption := range problemToList {
-		severity, ok := opts.ProblemSeverity[problem]
-		if ok && len(listOption) == 0 {
+		if len(listOption) > 0 {
+			continue
+		}
+		if severity, ok := opts.ProblemSeverity[problem]; ok {
 			err := newConfigError(dir,
 				"With option %ss=%s, corresponding option %s must be non-empty",
 				string(severity),
 				problem,
-				strings.Replace(problem, "bad-", "allow-", -1))
+				listOptionNames[problem])
 			return Options{}, err
 		}
+		for _, override := range opts.SeverityOverrides[problem] {
+			if override.Severity != SeverityIgnore {
+				err := newConfigError(dir,
+					"With option lint-%s-%s, corresponding option %s must be non-empty",
+					problem,
+					string(override.Severity),
+					listOptionNames[problem])
+				return Options{}, err
+			}
+		}
 	}
 
 	return opts, nil
@@ -4,6 +4,8 @@ import (
 	"reflect"
 	"regexp"
 	"testing"
+
+	"github.com/skeema/tengo"
 )
 
 func TestOptionsForDir(t *testing.T) {
@@ -17,10 +19,27 @@ func TestOptionsForDir(t *testing.T) {
 				"bad-charset": SeverityWarning,
 				"bad-engine":  SeverityWarning,
 			},
-			AllowedCharSets: []string{"utf8mb4"},
-			AllowedEngines:  []string{"innodb", "myisam"},
-			IgnoreSchema:    regexp.MustCompile(`^metadata$`),
-			IgnoreTable:     regexp.MustCompile(`^_`),
+			SeverityOverrides: map[string][]SeverityOverride{},
+			AllowedCharSets:   []string{"utf8mb4"},
+			AllowedEngines:    []string{"innodb", "myisam"},
+			AllowedPKTypes:    []string{"bigint"},
+			AllowedDefiners:   []string{"%@%"},
+			AllowFloat:        regexp.MustCompile(`^(lat|lng)$`),
+			RequiredSQLMode:   []string{},
+			RequiredComments:  []string{"table", "column"},
+			CommentMinLength:  1,
+			CommentExempt:     []string{"primary-key", "timestamp"},
+			MaxColumns:        100,
+			MaxIndexes:        16,
+			MaxIndexKeyLength: 0,
+			MaxRowSize:        65535,
+			MaxVarcharLength:  1024,
+			NamePatterns:      map[string]*regexp.Regexp{"table": regexp.MustCompile(`^[a-z0-9_]+$`)},
+			NamingDisallow:    []string{"reserved-word", "mixed-case"},
+			IgnoreSchema:      regexp.MustCompile(`^metadata$`),
+			IgnoreTable:       regexp.MustCompile(`^_`),
+			Normalize:         true,
+			LargeTableSize:    1024 * 1024 * 1024,
 		}
 		if !reflect.DeepEqual(opts, expected) {
 			t.Errorf("OptionsForDir returned %+v, did not match expectation %+v", opts, expected)
@@ -33,8 +52,31 @@ func TestOptionsForDir(t *testing.T) {
 		"--warnings='bad-charset,made-up-problem,bad-engine'",
 		"--ignore-table=+",
 		"--ignore-schema=+",
+		"--protect-table=+",
+		"--protect-column=+",
+		"--naming-table=+",
+		"--naming-foreign-key='('",
+		"--naming-disallow=lowercase",
 		"--allow-charset=''",
 		"--allow-engine='' --errors=''",
+		"--allow-pk-type='' --errors=pk-type",
+		"--allow-float=+",
+		"--allow-enum='('",
+		"--allow-timestamp=+",
+		"--warnings=bad-sql-mode",
+		"--large-table-size=huge",
+		"--require-comment=table,view",
+		"--require-comment='' --warnings=missing-comment",
+		"--require-comment-exempt=fk",
+		"--require-comment-min-length=0",
+		"--require-comment-min-length=long",
+		"--max-columns=0",
+		"--max-indexes=many",
+		"--max-index-key-length=3Q",
+		"--max-index-key-length=0",
+		"--max-row-size=0",
+		"--lint-no-pk-ignore=+",
+		"--allow-pk-type='' --lint-pk-type-error=^legacy_",
 	}
 	confirmError := func(cliArgs string) {
 		t.Helper()
@@ -56,3 +98,35 @@ func TestOptionsForDir(t *testing.T) {
 		t.Errorf("ConfigError not behaving as expected")
 	}
 }
+
+func TestSeverityFor(t *testing.T) {
+	dir := getDir(t, "../testdata/linter/validcfg", "--lint-no-pk-ignore=^legacy_", "--lint-no-pk-warning=^legacy_|^tmp_", "--lint-has-fk-error=^orders$")
+	opts, err := OptionsForDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error from OptionsForDir: %s", err)
+	}
+	cases := []struct {
+		problem  string
+		name     string
+		severity Severity
+		ok       bool
+	}{
+		{"no-pk", "users", SeverityError, true},
+		{"no-pk", "legacy_users", "", false}, // ignore takes precedence over warning
+		{"no-pk", "tmp_users", SeverityWarning, true},
+		{"has-fk", "orders", SeverityError, true},
+		{"has-fk", "users", "", false},
+		{"bad-engine", "orders", SeverityWarning, true},
+	}
+	for _, c := range cases {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: c.name}
+		if severity, ok := opts.SeverityFor(c.problem, key); severity != c.severity || ok != c.ok {
+			t.Errorf("Expected SeverityFor(%s, %s) to return %q,%t; instead found %q,%t", c.problem, key, c.severity, c.ok, severity, ok)
+		}
+	}
+
+	expected := []string{"bad-charset", "bad-engine", "has-fk", "no-pk"}
+	if enabled := opts.enabledProblems(); !reflect.DeepEqual(enabled, expected) {
+		t.Errorf("Expected enabledProblems to return %v, instead found %v", expected, enabled)
+	}
+}
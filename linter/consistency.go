@@ -0,0 +1,267 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// managedSchema represents one logical schema of a linted dir, along with the
+// schema names it maps to.
+type managedSchema struct {
+	names         []string
+	dir           *fs.Dir
+	logicalSchema *fs.LogicalSchema
+	schema        *tengo.Schema
+	opts          Options
+	suppressions  []*suppression
+}
+
+func (ms *managedSchema) String() string {
+	if len(ms.names) == 0 {
+		return ms.dir.RelPath()
+	}
+	return strings.Join(ms.names, ",")
+}
+
+// hasName returns true if ms maps to the supplied schema name.
+func (ms *managedSchema) hasName(name string) bool {
+	for _, n := range ms.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckConsistency compares schemas, previously obtained from LintDir on each
+// of dirs, to one another. Annotations are returned for foreign keys and
+// routines that reference a table missing from another linted schema, as well
+// as for same-named join columns that have different types in different
+// schemas, if the corresponding cross-schema-* problem is enabled and not
+// suppressed. Schemas mapped to a dir via a shellout or wildcard in the schema
+// option can only be compared by their columns, since their names are not
+// known without an instance.
+func CheckConsistency(dirs []*fs.Dir, schemas map[string]*tengo.Schema) *Result {
+	result := &Result{}
+	var managed []*managedSchema
+	byName := make(map[string][]*managedSchema)
+	for _, dir := range dirs {
+		opts, err := OptionsForDir(dir)
+		if err != nil {
+			continue // already reported by LintDir
+		}
+		for _, logicalSchema := range dir.LogicalSchemas {
+			schema := schemas[schemaKey(dir, logicalSchema)]
+			if schema == nil {
+				continue
+			}
+			ms := &managedSchema{
+				names:         []string{logicalSchema.Name},
+				dir:           dir,
+				logicalSchema: logicalSchema,
+				schema:        schema,
+				opts:          opts,
+				suppressions:  findSuppressions(logicalSchema),
+			}
+			if logicalSchema.Name == "" {
+				ms.names = literalSchemaNames(dir)
+			}
+			managed = append(managed, ms)
+			for _, name := range ms.names {
+				byName[name] = append(byName[name], ms)
+			}
+		}
+	}
+
+	hasTable := func(schemaName, tableName string) bool {
+		for _, ms := range byName[schemaName] {
+			for _, table := range ms.schema.Tables {
+				if table.Name == tableName {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	owners := make(map[*fs.Statement]*managedSchema)
+	for _, ms := range managed {
+		for _, stmt := range ms.logicalSchema.Creates {
+			owners[stmt] = ms
+		}
+		for _, a := range missingTableAnnotations(ms, byName, hasTable) {
+			a.Problem = "cross-schema-missing-table"
+			result.addDeferred(a, ms.opts, ms.suppressions)
+		}
+	}
+	for _, a := range joinColumnAnnotations(managed) {
+		a.Problem = "cross-schema-column-mismatch"
+		ms := owners[a.Statement]
+		result.addDeferred(a, ms.opts, ms.suppressions)
+	}
+	result.SortByFile()
+	return result
+}
+
+// literalSchemaNames returns the schema names configured for dir, as long as
+// they can be determined without an instance.
+func literalSchemaNames(dir *fs.Dir) []string {
+	if !dir.Config.Changed("schema") {
+		return nil
+	}
+	schemaValue := dir.Config.Get("schema")
+	if rawSchemaValue := dir.Config.GetRaw("schema"); (rawSchemaValue != schemaValue && rawSchemaValue[0] == '`') || schemaValue == "*" {
+		return nil
+	}
+	return dir.Config.GetSlice("schema", ',', true)
+}
+
+// missingTableAnnotations returns annotations for foreign keys and routines in
+// ms which reference a table in another managed schema, if that table does
+// not exist there. References to schemas that aren't in byName are not
+// considered, since they cannot be verified.
+func missingTableAnnotations(ms *managedSchema, byName map[string][]*managedSchema, hasTable func(schemaName, tableName string) bool) []*Annotation {
+	var results []*Annotation
+	isOther := func(schemaName string) bool {
+		return schemaName != "" && len(byName[schemaName]) > 0 && !ms.hasName(schemaName) && schemaName != ms.logicalSchema.Name
+	}
+	for _, table := range ms.schema.Tables {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		if ms.opts.ShouldIgnore(key) {
+			continue
+		}
+		stmt := ms.logicalSchema.Creates[key]
+		for _, fk := range table.ForeignKeys {
+			if !isOther(fk.ReferencedSchemaName) || hasTable(fk.ReferencedSchemaName, fk.ReferencedTableName) {
+				continue
+			}
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: foreignKeyLineOffset(fk, stmt),
+				Summary:    "Foreign key references missing table",
+				Message:    fmt.Sprintf("Foreign key %s of table %s references table %s.%s, but schema %s does not have a table by that name.", fk.Name, table.Name, fk.ReferencedSchemaName, fk.ReferencedTableName, fk.ReferencedSchemaName),
+			})
+		}
+	}
+
+	// Routine bodies are examined textually for schema-qualified table names.
+	// Qualified names followed by an opening paren are function calls, which are
+	// not considered.
+	schemaNames := make([]string, 0, len(byName))
+	for schemaName := range byName {
+		if isOther(schemaName) {
+			schemaNames = append(schemaNames, schemaName)
+		}
+	}
+	sort.Strings(schemaNames)
+	for _, schemaName := range schemaNames {
+		re := regexp.MustCompile(fmt.Sprintf("(?:^|[^\\w$.`])`?%s`?\\s*\\.\\s*`?([\\w$]+)`?(\\s*\\()?", regexp.QuoteMeta(schemaName)))
+		for _, routine := range ms.schema.Routines {
+			key := tengo.ObjectKey{Type: routine.Type, Name: routine.Name}
+			stmt := ms.logicalSchema.Creates[key]
+			for _, match := range re.FindAllStringSubmatchIndex(stmt.Text, -1) {
+				tableName := stmt.Text[match[2]:match[3]]
+				if match[4] > -1 || hasTable(schemaName, tableName) {
+					continue
+				}
+				results = append(results, &Annotation{
+					Statement:  stmt,
+					LineOffset: strings.Count(stmt.Text[:match[2]], "\n"),
+					Summary:    "Routine references missing table",
+					Message:    fmt.Sprintf("%s %s references table %s.%s, but schema %s does not have a table by that name.", strings.Title(string(routine.Type)), routine.Name, schemaName, tableName, schemaName),
+				})
+			}
+		}
+	}
+	return results
+}
+
+// joinColumn is a column whose name indicates it may be used to join to
+// tables in other schemas.
+type joinColumn struct {
+	ms         *managedSchema
+	table      *tengo.Table
+	col        *tengo.Column
+	definition string
+}
+
+var reIntDisplayWidth = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)`)
+
+// joinColumnAnnotations returns annotations for join columns that have a
+// different definition than the most common definition for columns of the
+// same name in other managed schemas. A column is considered to be a join
+// column if its name ends in "_id". Integer display widths are not considered
+// in comparing definitions.
+func joinColumnAnnotations(managed []*managedSchema) []*Annotation {
+	var results []*Annotation
+	byColName := make(map[string][]*joinColumn)
+	for _, ms := range managed {
+		for _, table := range ms.schema.Tables {
+			if ms.opts.ShouldIgnore(tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}) {
+				continue
+			}
+			for _, col := range table.Columns {
+				name := strings.ToLower(col.Name)
+				if !strings.HasSuffix(name, "_id") {
+					continue
+				}
+				definition := reIntDisplayWidth.ReplaceAllString(col.TypeInDB, "$1")
+				if col.Collation != "" {
+					definition = fmt.Sprintf("%s COLLATE %s", definition, col.Collation)
+				}
+				byColName[name] = append(byColName[name], &joinColumn{ms: ms, table: table, col: col, definition: definition})
+			}
+		}
+	}
+
+	names := make([]string, 0, len(byColName))
+	for name := range byColName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cols := byColName[name]
+		counts := make(map[string]int)
+		for _, jc := range cols {
+			counts[jc.definition]++
+		}
+		if len(counts) < 2 {
+			continue
+		}
+		var common string
+		for definition, count := range counts {
+			if count > counts[common] || (count == counts[common] && definition < common) {
+				common = definition
+			}
+		}
+		for _, jc := range cols {
+			if jc.definition == common {
+				continue
+			}
+			// Only flag the column if the common definition occurs in some other
+			// schema; differences within a single schema are out of scope here
+			var example *joinColumn
+			for _, other := range cols {
+				if other.definition == common && other.ms != jc.ms {
+					example = other
+					break
+				}
+			}
+			if example == nil {
+				continue
+			}
+			stmt := jc.ms.logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: jc.table.Name}]
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: columnLineOffset(jc.col, stmt),
+				Summary:    "Join column type differs across schemas",
+				Message:    fmt.Sprintf("Column %s of table %s has definition %s, but %d other column(s) of the same name have definition %s, for example table %s in schema %s. Joins or comparisons between these columns may be inefficient or lossy.", jc.col.Name, jc.table.Name, jc.definition, counts[common], common, example.table.Name, example.ms),
+			})
+		}
+	}
+	return results
+}
@@ -0,0 +1,92 @@
+package linter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestCrossSchemaAnnotations(t *testing.T) {
+	tableKey := func(table *tengo.Table) tengo.ObjectKey {
+		return tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+	}
+	ordersUserID := &tengo.Column{Name: "user_id", TypeInDB: "int(10) unsigned"}
+	orders := &tengo.Table{
+		Name:    "orders",
+		Columns: []*tengo.Column{{Name: "id", TypeInDB: "int(10) unsigned"}, ordersUserID},
+		ForeignKeys: []*tengo.ForeignKey{
+			{Name: "fk_user", Columns: []*tengo.Column{ordersUserID}, ReferencedSchemaName: "accounts", ReferencedTableName: "users", ReferencedColumnNames: []string{"id"}},
+			{Name: "fk_gone", Columns: []*tengo.Column{ordersUserID}, ReferencedSchemaName: "accounts", ReferencedTableName: "gone", ReferencedColumnNames: []string{"id"}},
+			{Name: "fk_ext", Columns: []*tengo.Column{ordersUserID}, ReferencedSchemaName: "unmanaged", ReferencedTableName: "gone", ReferencedColumnNames: []string{"id"}},
+		},
+	}
+	proc := &tengo.Routine{Name: "archive", Type: tengo.ObjectTypeProc}
+	procKey := tengo.ObjectKey{Type: tengo.ObjectTypeProc, Name: proc.Name}
+	appStmts := map[tengo.ObjectKey]*fs.Statement{
+		tableKey(orders): {Text: "CREATE TABLE orders (\n  id int unsigned,\n  user_id int unsigned,\n  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES accounts.users (id),\n  CONSTRAINT fk_gone FOREIGN KEY (user_id) REFERENCES accounts.gone (id),\n  CONSTRAINT fk_ext FOREIGN KEY (user_id) REFERENCES unmanaged.gone (id)\n)"},
+		procKey:          {Text: "CREATE PROCEDURE archive()\nBEGIN\n  SELECT * FROM accounts.users;\n  SELECT * FROM `accounts`.`missing_tbl`;\n  SELECT accounts.somefunc(1), xaccounts.nope;\nEND"},
+	}
+	app := &managedSchema{
+		names:         []string{"app"},
+		logicalSchema: &fs.LogicalSchema{Creates: appStmts},
+		schema:        &tengo.Schema{Name: "_skeema_tmp", Tables: []*tengo.Table{orders}, Routines: []*tengo.Routine{proc}},
+	}
+
+	users := &tengo.Table{Name: "users", Columns: []*tengo.Column{{Name: "id", TypeInDB: "int(10) unsigned"}}}
+	logins := &tengo.Table{Name: "logins", Columns: []*tengo.Column{{Name: "user_id", TypeInDB: "int(11) unsigned"}}}
+	sessions := &tengo.Table{Name: "sessions", Columns: []*tengo.Column{{Name: "id", TypeInDB: "bigint(20)"}, {Name: "user_id", TypeInDB: "int(11)"}}}
+	accountsStmts := map[tengo.ObjectKey]*fs.Statement{
+		tableKey(users):    {Text: "CREATE TABLE users (id int unsigned)"},
+		tableKey(logins):   {Text: "CREATE TABLE logins (user_id int unsigned)"},
+		tableKey(sessions): {Text: "CREATE TABLE sessions (\n  id bigint,\n  user_id int\n)"},
+	}
+	accounts := &managedSchema{
+		names:         []string{"accounts"},
+		logicalSchema: &fs.LogicalSchema{Creates: accountsStmts},
+		schema:        &tengo.Schema{Name: "_skeema_tmp", Tables: []*tengo.Table{users, logins, sessions}},
+	}
+
+	byName := map[string][]*managedSchema{"app": {app}, "accounts": {accounts}}
+	hasTable := func(schemaName, tableName string) bool {
+		return schemaName == "accounts" && (tableName == "users" || tableName == "logins" || tableName == "sessions")
+	}
+	annotations := missingTableAnnotations(app, byName, hasTable)
+	if len(annotations) != 2 {
+		t.Fatalf("Expected 2 annotations, instead found %d: %+v", len(annotations), annotations)
+	}
+	if a := annotations[0]; a.Statement != appStmts[tableKey(orders)] || a.LineOffset != 4 {
+		t.Errorf("Unexpected annotation for foreign key: %+v", a)
+	}
+	if a := annotations[1]; a.Statement != appStmts[procKey] || a.LineOffset != 3 {
+		t.Errorf("Unexpected annotation for routine: %+v", a)
+	}
+	if annotations := missingTableAnnotations(accounts, byName, hasTable); len(annotations) != 0 {
+		t.Errorf("Expected 0 annotations, instead found %d: %+v", len(annotations), annotations)
+	}
+
+	annotations = joinColumnAnnotations([]*managedSchema{app, accounts})
+	if len(annotations) != 1 {
+		t.Fatalf("Expected 1 annotation, instead found %d: %+v", len(annotations), annotations)
+	}
+	if a := annotations[0]; a.Statement != accountsStmts[tableKey(sessions)] || a.LineOffset != 2 {
+		t.Errorf("Unexpected annotation for join column: %+v", a)
+	}
+
+	// Differences within a single schema are not flagged
+	if annotations := joinColumnAnnotations([]*managedSchema{accounts}); len(annotations) != 0 {
+		t.Errorf("Expected 0 annotations, instead found %d: %+v", len(annotations), annotations)
+	}
+}
+
+func TestLiteralSchemaNames(t *testing.T) {
+	dir := getDir(t, "../testdata/linter/validcfg")
+	if names := literalSchemaNames(dir); !reflect.DeepEqual(names, []string{"whatever"}) {
+		t.Errorf("Unexpected result from literalSchemaNames: %v", names)
+	}
+	dir = getDir(t, "../testdata/linter/validcfg", "--schema=*")
+	if names := literalSchemaNames(dir); names != nil {
+		t.Errorf("Unexpected result from literalSchemaNames: %v", names)
+	}
+}
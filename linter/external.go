@@ -0,0 +1,110 @@
+package linter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+// externalInput is the JSON document supplied to an external linter's STDIN.
+type externalInput struct {
+	SchemaName string              `json:"schema_name"`
+	Schema     *tengo.Schema       `json:"schema"`
+	Statements []externalStatement `json:"statements"`
+}
+
+// externalStatement describes the location of one CREATE statement from the
+// filesystem, for use by an external linter.
+type externalStatement struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// externalAnnotation is a single annotation, as returned by an external linter
+// on its STDOUT.
+type externalAnnotation struct {
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	Problem    string `json:"problem"`
+	LineOffset int    `json:"line_offset"`
+	Summary    string `json:"summary"`
+	Message    string `json:"message"`
+}
+
+// runExternalLinter supplies schema and the statements of logicalSchema to the
+// external linter command s, and converts its output to annotations. The
+// command must exit with code 0 and output a JSON object with an "annotations"
+// array, even if empty. An error is returned if the command fails, its output
+// cannot be parsed, or an annotation refers to an object not present in
+// logicalSchema.
+func runExternalLinter(s *util.ShellOut, schema *tengo.Schema, logicalSchema *fs.LogicalSchema) ([]*Annotation, error) {
+	input := externalInput{
+		SchemaName: logicalSchema.Name,
+		Schema:     schema,
+		Statements: []externalStatement{},
+	}
+	for key, stmt := range logicalSchema.Creates {
+		input.Statements = append(input.Statements, externalStatement{
+			Type: string(key.Type),
+			Name: key.Name,
+			File: stmt.File,
+			Line: stmt.LineNo,
+			Text: stmt.Text,
+		})
+	}
+	sort.Slice(input.Statements, func(i, j int) bool {
+		if input.Statements[i].File != input.Statements[j].File {
+			return input.Statements[i].File < input.Statements[j].File
+		}
+		return input.Statements[i].Line < input.Statements[j].Line
+	})
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy s, to avoid mutating shared state when setting Stdin
+	cmd := *s
+	cmd.Stdin = bytes.NewReader(inputJSON)
+	output, err := cmd.RunCapture()
+	if err != nil {
+		return nil, fmt.Errorf("External linter %s failed: %s", s, err)
+	}
+	var parsed struct {
+		Annotations []externalAnnotation `json:"annotations"`
+	}
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		return nil, fmt.Errorf("Unable to parse output of external linter %s: %s", s, err)
+	}
+
+	annotations := make([]*Annotation, 0, len(parsed.Annotations))
+	for _, ea := range parsed.Annotations {
+		key := tengo.ObjectKey{Type: tengo.ObjectType(ea.Type), Name: ea.Name}
+		stmt := logicalSchema.Creates[key]
+		if stmt == nil {
+			return nil, fmt.Errorf("External linter %s returned annotation for %s, which does not exist", s, key)
+		} else if ea.Problem == "" || ea.Message == "" {
+			return nil, fmt.Errorf("External linter %s returned annotation for %s without a problem name and message", s, key)
+		}
+		a := &Annotation{
+			Statement:  stmt,
+			LineOffset: ea.LineOffset,
+			Summary:    ea.Summary,
+			Message:    ea.Message,
+			Problem:    ea.Problem,
+		}
+		if a.Summary == "" {
+			a.Summary = "External linter problem"
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations, nil
+}
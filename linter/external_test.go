@@ -0,0 +1,81 @@
+package linter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+func TestRunExternalLinter(t *testing.T) {
+	stmt := &fs.Statement{
+		File:       "/base/foo.sql",
+		LineNo:     1,
+		Text:       "CREATE TABLE foo (\n  id int\n);\n",
+		Type:       fs.StatementTypeCreate,
+		ObjectType: tengo.ObjectTypeTable,
+		ObjectName: "foo",
+	}
+	logicalSchema := &fs.LogicalSchema{
+		Name:    "mydb",
+		Creates: map[tengo.ObjectKey]*fs.Statement{stmt.ObjectKey(): stmt},
+	}
+	schema := &tengo.Schema{
+		Name:   "_skeema_tmp",
+		Tables: []*tengo.Table{{Name: "foo", Columns: []*tengo.Column{{Name: "id", TypeInDB: "int(11)"}}}},
+	}
+
+	// Confirm input is passed on STDIN, and output is converted to annotations
+	inputFile := "external-linter-input.json"
+	defer os.Remove(inputFile)
+	s := &util.ShellOut{
+		Command: `cat > ` + inputFile + `; echo '{"annotations": [{"type": "table", "name": "foo", "problem": "int-width", "line_offset": 1, "message": "Column id has a display width"}]}'`,
+	}
+	annotations, err := runExternalLinter(s, schema, logicalSchema)
+	if err != nil {
+		t.Fatalf("Unexpected error from runExternalLinter: %s", err)
+	}
+	if len(annotations) != 1 {
+		t.Fatalf("Expected 1 annotation, instead found %d", len(annotations))
+	}
+	expected := Annotation{
+		Statement:  stmt,
+		LineOffset: 1,
+		Summary:    "External linter problem",
+		Message:    "Column id has a display width",
+		Problem:    "int-width",
+	}
+	if *annotations[0] != expected {
+		t.Errorf("Expected annotation %+v, instead found %+v", expected, *annotations[0])
+	}
+	var input externalInput
+	if contents, err := ioutil.ReadFile(inputFile); err != nil {
+		t.Fatalf("Unable to read input file: %s", err)
+	} else if err := json.Unmarshal(contents, &input); err != nil {
+		t.Fatalf("Unable to parse input: %s", err)
+	}
+	if input.SchemaName != "mydb" || len(input.Schema.Tables) != 1 || input.Schema.Tables[0].Columns[0].TypeInDB != "int(11)" {
+		t.Errorf("Unexpected input schema: %+v", input)
+	}
+	if len(input.Statements) != 1 || input.Statements[0] != (externalStatement{Type: "table", Name: "foo", File: "/base/foo.sql", Line: 1, Text: stmt.Text}) {
+		t.Errorf("Unexpected input statements: %+v", input.Statements)
+	}
+
+	// Confirm error conditions
+	errCommands := []string{
+		"false",
+		"echo 'not json'",
+		`echo '{"annotations": [{"type": "table", "name": "bar", "problem": "x", "message": "y"}]}'`,
+		`echo '{"annotations": [{"type": "table", "name": "foo", "message": "y"}]}'`,
+	}
+	for _, command := range errCommands {
+		s := &util.ShellOut{Command: command}
+		if _, err := runExternalLinter(s, schema, logicalSchema); err == nil {
+			t.Errorf("Expected command %s to cause an error, but err was nil", command)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package linter
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// ApplyFix replaces the text of a's statement with a.Fix, and then rewrites
+// the file containing the statement. An error is returned if a has no fix, or
+// if the file cannot be written.
+func (a *Annotation) ApplyFix() error {
+	if a.Fix == "" || a.Statement.FromFile == nil {
+		return errors.New("No fix available")
+	}
+	_, suffix := a.Statement.SplitTextBody()
+	a.Statement.Text = a.Fix + suffix
+	_, err := a.Statement.FromFile.Rewrite()
+	return err
+}
+
+// fixedCreateTable returns a CREATE TABLE statement for a copy of table, after
+// modify has been applied to the copy. The copy's columns and list of secondary
+// indexes may be modified freely. An empty string is returned if table uses
+// features that prevent generating an accurate CREATE TABLE.
+func fixedCreateTable(table *tengo.Table, flavor tengo.Flavor, modify func(*tengo.Table)) string {
+	if table.UnsupportedDDL {
+		return ""
+	}
+	t := *table
+	t.Columns = make([]*tengo.Column, len(table.Columns))
+	for n, col := range table.Columns {
+		colCopy := *col
+		t.Columns[n] = &colCopy
+	}
+	t.SecondaryIndexes = append([]*tengo.Index(nil), table.SecondaryIndexes...)
+	modify(&t)
+	return t.GeneratedCreateStatement(flavor)
+}
+
+// isUTF8MB3 returns true if charSet is the legacy 3-byte utf8 character set.
+func isUTF8MB3(charSet string) bool {
+	return charSet == "utf8" || charSet == "utf8mb3"
+}
+
+// utf8mb4CollationSuffixes lists the collations of the legacy utf8 character
+// set which have a utf8mb4 equivalent, keyed by the collation name without its
+// character set prefix. Some utf8 collations, such as utf8_general_mysql500_ci
+// and utf8_tolower_ci, have no utf8mb4 equivalent and are intentionally absent.
+var utf8mb4CollationSuffixes = map[string]bool{
+	"_general_ci":           true,
+	"_bin":                  true,
+	"_unicode_ci":           true,
+	"_icelandic_ci":         true,
+	"_latvian_ci":           true,
+	"_romanian_ci":          true,
+	"_slovenian_ci":         true,
+	"_polish_ci":            true,
+	"_estonian_ci":          true,
+	"_spanish_ci":           true,
+	"_swedish_ci":           true,
+	"_turkish_ci":           true,
+	"_czech_ci":             true,
+	"_danish_ci":            true,
+	"_lithuanian_ci":        true,
+	"_slovak_ci":            true,
+	"_spanish2_ci":          true,
+	"_roman_ci":             true,
+	"_persian_ci":           true,
+	"_esperanto_ci":         true,
+	"_hungarian_ci":         true,
+	"_sinhala_ci":           true,
+	"_german2_ci":           true,
+	"_croatian_ci":          true,
+	"_unicode_520_ci":       true,
+	"_vietnamese_ci":        true,
+	"_general_nopad_ci":     true, // MariaDB only
+	"_nopad_bin":            true, // MariaDB only
+	"_unicode_nopad_ci":     true, // MariaDB only
+	"_unicode_520_nopad_ci": true, // MariaDB only
+	"_croatian_mysql561_ci": true, // MariaDB only
+	"_myanmar_ci":           true, // MariaDB only
+	"_thai_520_w2":          true, // MariaDB only
+}
+
+// utf8mb4Fix returns a CREATE TABLE for table with its default character set
+// and all of its columns converted from utf8 to utf8mb4. Default collations
+// remain default; other collations are converted to their utf8mb4 equivalent.
+// If any collation has no known utf8mb4 equivalent, an empty string is
+// returned.
+func utf8mb4Fix(table *tengo.Table, flavor tengo.Flavor) string {
+	unmapped := false
+	convert := func(charSet, collation string, collationIsDefault bool) (string, string) {
+		if !isUTF8MB3(charSet) {
+			return charSet, collation
+		} else if collationIsDefault {
+			return "utf8mb4", ""
+		}
+		var suffix string
+		if pos := strings.Index(collation, "_"); pos > -1 {
+			suffix = collation[pos:]
+		}
+		if !utf8mb4CollationSuffixes[suffix] {
+			unmapped = true
+			return charSet, collation
+		}
+		return "utf8mb4", "utf8mb4" + suffix
+	}
+	fix := fixedCreateTable(table, flavor, func(t *tengo.Table) {
+		t.CharSet, t.Collation = convert(t.CharSet, t.Collation, t.CollationIsDefault)
+		for _, col := range t.Columns {
+			if col.CharSet != "" {
+				col.CharSet, col.Collation = convert(col.CharSet, col.Collation, col.CollationIsDefault)
+			}
+		}
+	})
+	if unmapped {
+		return ""
+	}
+	return fix
+}
+
+// innoDBFix returns a CREATE TABLE for table with its storage engine changed
+// to InnoDB.
+func innoDBFix(table *tengo.Table, flavor tengo.Flavor) string {
+	return fixedCreateTable(table, flavor, func(t *tengo.Table) {
+		t.Engine = "InnoDB"
+	})
+}
+
+// fkIndexFix returns a CREATE TABLE for table with an explicit index for fk.
+// If the server already created an index for fk implicitly, it is retained as
+// an explicit one; otherwise a new index is added, with the same name as fk.
+func fkIndexFix(table *tengo.Table, fk *tengo.ForeignKey, flavor tengo.Flavor) string {
+	return fixedCreateTable(table, flavor, func(t *tengo.Table) {
+		if !hasIndexForColumns(t, fk.Columns) {
+			t.SecondaryIndexes = append(t.SecondaryIndexes, &tengo.Index{
+				Name:     fk.Name,
+				Columns:  fk.Columns,
+				SubParts: make([]uint16, len(fk.Columns)),
+			})
+		}
+	})
+}
+
+// hasIndexForColumns returns true if table has an index (including the
+// primary key) whose leftmost columns are cols.
+func hasIndexForColumns(table *tengo.Table, cols []*tengo.Column) bool {
+	if table.PrimaryKey != nil && columnsArePrefix(cols, table.PrimaryKey) {
+		return true
+	}
+	for _, idx := range table.SecondaryIndexes {
+		if columnsArePrefix(cols, idx) {
+			return true
+		}
+	}
+	return false
+}
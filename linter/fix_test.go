@@ -0,0 +1,95 @@
+package linter
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestFixes(t *testing.T) {
+	flavor := tengo.NewFlavor("mysql:5.7")
+	cols := []*tengo.Column{
+		{Name: "id", TypeInDB: "int(10) unsigned", Default: tengo.ColumnDefaultNull},
+		{Name: "name", TypeInDB: "varchar(30)", Nullable: true, CharSet: "utf8", Collation: "utf8_general_ci", CollationIsDefault: true, Default: tengo.ColumnDefaultNull},
+		{Name: "code", TypeInDB: "varchar(10)", Nullable: true, CharSet: "utf8", Collation: "utf8_bin", Default: tengo.ColumnDefaultNull},
+		{Name: "parent_id", TypeInDB: "int(10) unsigned", Nullable: true, Default: tengo.ColumnDefaultNull},
+	}
+	table := &tengo.Table{
+		Name:               "t",
+		Engine:             "MyISAM",
+		CharSet:            "utf8",
+		Collation:          "utf8_general_ci",
+		CollationIsDefault: true,
+		Columns:            cols,
+		PrimaryKey:         &tengo.Index{Name: "PRIMARY", Columns: cols[0:1], SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		ForeignKeys:        []*tengo.ForeignKey{{Name: "fk_parent", Columns: cols[3:4], ReferencedTableName: "t", ReferencedColumnNames: []string{"id"}, UpdateRule: "RESTRICT", DeleteRule: "RESTRICT"}},
+	}
+	origCreate := table.GeneratedCreateStatement(flavor)
+
+	fix := utf8mb4Fix(table, flavor)
+	if !strings.Contains(fix, "`name` varchar(30) DEFAULT NULL,") || !strings.Contains(fix, "`code` varchar(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin DEFAULT NULL,") || !strings.HasSuffix(fix, "DEFAULT CHARSET=utf8mb4") {
+		t.Errorf("Unexpected result from utf8mb4Fix: %s", fix)
+	}
+	for _, collation := range []string{"utf8_general_mysql500_ci", "utf8mb3_tolower_ci"} {
+		cols[2].Collation = collation
+		if fix = utf8mb4Fix(table, flavor); fix != "" {
+			t.Errorf("Expected no fix for collation %s, instead found %s", collation, fix)
+		}
+	}
+	cols[2].Collation = "utf8mb3_unicode_520_ci"
+	if fix = utf8mb4Fix(table, flavor); !strings.Contains(fix, "COLLATE utf8mb4_unicode_520_ci") {
+		t.Errorf("Unexpected result from utf8mb4Fix: %s", fix)
+	}
+	cols[2].Collation = "utf8_bin"
+	if fix = innoDBFix(table, flavor); !strings.Contains(fix, "ENGINE=InnoDB DEFAULT CHARSET=utf8") {
+		t.Errorf("Unexpected result from innoDBFix: %s", fix)
+	}
+	if fix = fkIndexFix(table, table.ForeignKeys[0], flavor); !strings.Contains(fix, "  KEY `fk_parent` (`parent_id`),\n") {
+		t.Errorf("Unexpected result from fkIndexFix: %s", fix)
+	}
+
+	// Confirm the original table was not modified by any of the fixes
+	if table.GeneratedCreateStatement(flavor) != origCreate {
+		t.Errorf("Fixes unexpectedly modified original table")
+	}
+	table.UnsupportedDDL = true
+	if fix = innoDBFix(table, flavor); fix != "" {
+		t.Errorf("Expected no fix for table with unsupported DDL, instead found %s", fix)
+	}
+}
+
+func TestAnnotationApplyFix(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "applyfix")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dirPath)
+	sqlFile := fs.SQLFile{Dir: dirPath, FileName: "t.sql"}
+	if err := sqlFile.Create("CREATE TABLE t (id int) ENGINE=MyISAM;\n-- a comment\n"); err != nil {
+		t.Fatalf("Unable to create file: %s", err)
+	}
+	tokenizedFile, err := sqlFile.Tokenize()
+	if err != nil {
+		t.Fatalf("Unexpected error from Tokenize: %s", err)
+	}
+	stmt := tokenizedFile.Statements[0]
+
+	a := &Annotation{Statement: stmt}
+	if err := a.ApplyFix(); err == nil {
+		t.Error("Expected error from ApplyFix without a fix, but err was nil")
+	}
+	a.Fix = "CREATE TABLE `t` (\n  `id` int(11) DEFAULT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=latin1"
+	if err := a.ApplyFix(); err != nil {
+		t.Fatalf("Unexpected error from ApplyFix: %s", err)
+	}
+	expected := a.Fix + ";\n-- a comment\n"
+	if contents, err := ioutil.ReadFile(sqlFile.Path()); err != nil {
+		t.Fatalf("Unable to read file: %s", err)
+	} else if string(contents) != expected {
+		t.Errorf("Unexpected file contents after ApplyFix: %q", contents)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/skeema/workspace"
 	"github.com/skeema/tengo"
 )
@@ -21,6 +22,7 @@ type Annotation struct {
 	Summary    string
 	Message    string
 	Problem    string
+	Fix        string // if non-empty, a replacement for the statement's text which resolves the problem
 }
 
 // MessageWithLocation prepends statement location information to a.Message,
@@ -110,6 +112,25 @@ func (r *Result) SortByFile() {
 	sort.Sort(sortByFile(r.FormatNotices))
 }
 
+// Filter removes errors, warnings, and format notices from r in-place, unless
+// keep returns true for them.
+func (r *Result) Filter(keep func(*Annotation) bool) {
+	if r == nil {
+		return
+	}
+	filter := func(annotations []*Annotation) (kept []*Annotation) {
+		for _, a := range annotations {
+			if keep(a) {
+				kept = append(kept, a)
+			}
+		}
+		return kept
+	}
+	r.Errors = filter(r.Errors)
+	r.Warnings = filter(r.Warnings)
+	r.FormatNotices = filter(r.FormatNotices)
+}
+
 // BadConfigResult returns a *Result containing a single ConfigError in the
 // Exceptions field. The supplied err will be converted to a ConfigError if it
 // is not already one.
@@ -132,28 +153,13 @@ func LintDir(dir *fs.Dir, wsOpts workspace.Options) *Result {
 
 	result := &Result{}
 	for _, logicalSchema := range dir.LogicalSchemas {
-		// ignore-schema is handled relatively simplistically here: skip dir entirely
-		// if any literal schema name matches the pattern, but don't bother
-		// interpretting schema=`shellout` or schema=*, which require an instance.
-		if opts.IgnoreSchema != nil {
-			var foundIgnoredName bool
-			for _, schemaName := range dir.Config.GetSlice("schema", ',', true) {
-				if opts.IgnoreSchema.MatchString(schemaName) {
-					foundIgnoredName = true
-				}
-			}
-			if foundIgnoredName {
-				result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping schema in %s because ignore-schema='%s'", dir.RelPath(), opts.IgnoreSchema))
-				return result
-			}
+		if ignoredSchemaDir(dir, opts) {
+			result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping schema in %s because ignore-schema='%s'", dir.RelPath(), opts.IgnoreSchema))
+			return result
 		}
 		schema, res := ExecLogicalSchema(logicalSchema, wsOpts, opts)
 		if schema != nil {
-			schemaKey := dir.Path
-			if logicalSchema.Name != "" {
-				schemaKey = fmt.Sprintf("%s:%s", schemaKey, logicalSchema.Name)
-			}
-			res.Schemas = map[string]*tengo.Schema{schemaKey: schema}
+			res.Schemas = map[string]*tengo.Schema{schemaKey(dir, logicalSchema): schema}
 		}
 		result.Merge(res)
 	}
@@ -176,6 +182,50 @@ func LintDir(dir *fs.Dir, wsOpts workspace.Options) *Result {
 	return result
 }
 
+// FormatDir checks the format of all logical schemas in dir, without checking
+// for any problems. Each CREATE statement that does not match the canonical
+// format from SHOW CREATE has its text replaced, and results in a format
+// notice. Invalid SQL results in an error annotation. Does not recurse into
+// subdirs.
+func FormatDir(dir *fs.Dir, wsOpts workspace.Options) *Result {
+	opts := Options{Normalize: true}
+	var err error
+	if opts.IgnoreSchema, err = dir.Config.GetRegexp("ignore-schema"); err != nil {
+		return BadConfigResult(dir, err)
+	}
+	if opts.IgnoreTable, err = dir.Config.GetRegexp("ignore-table"); err != nil {
+		return BadConfigResult(dir, err)
+	}
+
+	result := &Result{}
+	for _, logicalSchema := range dir.LogicalSchemas {
+		if ignoredSchemaDir(dir, opts) {
+			result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping schema in %s because ignore-schema='%s'", dir.RelPath(), opts.IgnoreSchema))
+			return result
+		}
+		_, res, _ := execLogicalSchema(logicalSchema, wsOpts, opts)
+		result.Merge(res)
+	}
+	result.SortByFile()
+	return result
+}
+
+// ignoredSchemaDir returns true if dir should be skipped due to the
+// ignore-schema option. This is handled relatively simplistically: skip dir
+// entirely if any literal schema name matches the pattern, but don't bother
+// interpretting schema=`shellout` or schema=*, which require an instance.
+func ignoredSchemaDir(dir *fs.Dir, opts Options) bool {
+	if opts.IgnoreSchema == nil {
+		return false
+	}
+	for _, schemaName := range dir.Config.GetSlice("schema", ',', true) {
+		if opts.IgnoreSchema.MatchString(schemaName) {
+			return true
+		}
+	}
+	return false
+}
+
 var reSyntaxErrorLine = regexp.MustCompile(`(?s) the right syntax to use near '.*' at line (\d+)`)
 
 // ExecLogicalSchema is a wrapper around workspace.ExecLogicalSchema. After the
@@ -183,6 +233,120 @@ var reSyntaxErrorLine = regexp.MustCompile(`(?s) the right syntax to use near '.
 // are captured as part of the *Result. However, the schema itself is not yet
 // placed into the *Result; this is the caller's responsibility.
 func ExecLogicalSchema(logicalSchema *fs.LogicalSchema, wsOpts workspace.Options, opts Options) (*tengo.Schema, *Result) {
+	schema, result, suppressions := execLogicalSchema(logicalSchema, wsOpts, opts)
+	if schema == nil {
+		return nil, result
+	}
+
+	addAnnotation := func(a *Annotation, severity Severity) {
+		// Fixes replace the entire statement, which would lose any comments
+		if a.Fix != "" && hasInlineSuppression(a.Statement, suppressions) {
+			a.Fix = ""
+		}
+		if opts.ShouldIgnore(a.Statement.ObjectKey()) {
+			result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping %s because ignore-table='%s'", a.Statement.ObjectKey(), opts.IgnoreTable))
+		} else if suppress(a, suppressions) {
+			result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Suppressing %s for %s due to skeema:disable comment", a.Problem, a.Statement.ObjectKey()))
+		} else if severity == SeverityWarning {
+			result.Warnings = append(result.Warnings, a)
+		} else {
+			result.Errors = append(result.Errors, a)
+		}
+	}
+	for _, problemName := range opts.enabledProblems() {
+		annotations := problems[problemName](schema, logicalSchema, opts)
+		for _, a := range annotations {
+			a.Problem = problemName
+			if severity, ok := opts.SeverityFor(problemName, a.Statement.ObjectKey()); ok {
+				addAnnotation(a, severity)
+			} else {
+				result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping %s for %s due to per-object severity override", problemName, a.Statement.ObjectKey()))
+			}
+		}
+	}
+	if opts.ExternalLinter != nil {
+		annotations, err := runExternalLinter(opts.ExternalLinter, schema, logicalSchema)
+		if err != nil {
+			result.Exceptions = append(result.Exceptions, err)
+		}
+		for _, a := range annotations {
+			addAnnotation(a, opts.ExternalSeverity)
+		}
+	}
+
+	// Warn about suppressions that no longer match anything. Suppressions of
+	// problems that aren't currently enabled are only flagged if the problem
+	// name is not valid at all. If an external linter is in use, any problem
+	// name may be valid, so unknown names are treated as enabled. Suppressions
+	// of deferred problems are skipped, since they aren't detected here.
+	for _, s := range suppressions {
+		if s.used || opts.ShouldIgnore(s.target.ObjectKey()) || isDeferredProblem(s.problem) {
+			continue
+		}
+		a := &Annotation{
+			Statement:  s.comment,
+			LineOffset: s.lineOffset,
+			Summary:    "Unused lint suppression",
+		}
+		if _, ok := problems[s.problem]; !ok && opts.ExternalLinter == nil {
+			a.Message = fmt.Sprintf("skeema:disable comment refers to unknown problem %q", s.problem)
+		} else if _, ok := opts.SeverityFor(s.problem, s.target.ObjectKey()); ok || !problemExists(s.problem) {
+			a.Message = fmt.Sprintf("skeema:disable comment for %s no longer matches any problem in %s, and can be removed", s.problem, s.target.ObjectKey())
+		} else {
+			continue
+		}
+		result.Warnings = append(result.Warnings, a)
+	}
+
+	return schema, result
+}
+
+// deferredDetector is the Detector for problems which cannot be detected from
+// a single logical schema in a workspace: plan-* problems are detected by
+// CheckPlan, cross-schema-* problems by CheckConsistency, and upgrade-*
+// problems by CheckUpgrade. Registering them
+// permits configuring and suppressing them like any other problem, but this
+// function never returns any annotations.
+func deferredDetector(_ *tengo.Schema, _ *fs.LogicalSchema, _ Options) []*Annotation {
+	return nil
+}
+
+// isDeferredProblem returns true if problem is only detected outside of
+// ExecLogicalSchema.
+func isDeferredProblem(problem string) bool {
+	for _, prefix := range []string{"plan-", "cross-schema-", "upgrade-"} {
+		if strings.HasPrefix(problem, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// addDeferred adds a, an annotation for a deferred problem, to r as an error or
+// warning based on the severity configured for a.Problem. The annotation is
+// omitted if its problem is not enabled for its object, or if it is suppressed
+// by one of suppressions.
+func (r *Result) addDeferred(a *Annotation, opts Options, suppressions []*suppression) {
+	key := a.Statement.ObjectKey()
+	severity, ok := opts.SeverityFor(a.Problem, key)
+	if !ok {
+		return
+	}
+	if suppress(a, suppressions) {
+		r.DebugLogs = append(r.DebugLogs, fmt.Sprintf("Suppressing %s for %s due to skeema:disable comment", a.Problem, key))
+	} else if severity == SeverityError {
+		r.Errors = append(r.Errors, a)
+	} else {
+		r.Warnings = append(r.Warnings, a)
+	}
+}
+
+// execLogicalSchema converts logicalSchema into a real schema using a
+// workspace, and then checks the format of each CREATE statement. It returns
+// the schema, a result containing any SQL errors and format notices, and any
+// skeema:disable comments found in logicalSchema. If a fatal error occurs, the
+// returned schema will be nil.
+func execLogicalSchema(logicalSchema *fs.LogicalSchema, wsOpts workspace.Options, opts Options) (*tengo.Schema, *Result, []*suppression) {
 	result := &Result{}
 
 	// Convert the logical schema from the filesystem into a real schema, using a
@@ -190,7 +354,7 @@ func ExecLogicalSchema(logicalSchema *fs.LogicalSchema, wsOpts workspace.Options
 	schema, statementErrors, err := workspace.ExecLogicalSchema(logicalSchema, wsOpts)
 	if err != nil {
 		result.Exceptions = append(result.Exceptions, err)
-		return nil, result
+		return nil, result, nil
 	}
 	for _, stmtErr := range statementErrors {
 		if opts.ShouldIgnore(stmtErr.ObjectKey()) {
@@ -211,40 +375,127 @@ func ExecLogicalSchema(logicalSchema *fs.LogicalSchema, wsOpts workspace.Options
 		result.Errors = append(result.Errors, a)
 	}
 
+	// Suppression comments must be located before reformatting, since
+	// reformatting strips comments from within statements.
+	suppressions := findSuppressions(logicalSchema)
+
 	// It's important to check format prior to checking problems. Otherwise, the
 	// relative line offsets for the problem annotations can be incorrect.
 	// Compare each canonical CREATE in the real schema to each CREATE statement
 	// from the filesystem. In cases where they differ, emit a notice to reformat
-	// the file using the canonical version from the DB.
+	// the file using the canonical version from the DB, unless opts.Normalize is
+	// false. Statements containing suppression comments are not reformatted, to
+	// avoid losing the comments.
 	for key, instCreateText := range schema.ObjectDefinitions() {
 		fsStmt := logicalSchema.Creates[key]
 		fsBody, fsSuffix := fsStmt.SplitTextBody()
 		if instCreateText != fsBody {
 			if opts.ShouldIgnore(key) {
 				result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping %s because ignore-table='%s'", key, opts.IgnoreTable))
+			} else if hasInlineSuppression(fsStmt, suppressions) {
+				result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Not reformatting %s because it contains skeema:disable comments", key))
 			} else {
-				fsStmt.Text = fmt.Sprintf("%s%s", instCreateText, fsSuffix)
+				// If not normalizing, the canonical format is provided as a fix instead
+				var fix string
+				if opts.Normalize {
+					fsStmt.Text = fmt.Sprintf("%s%s", instCreateText, fsSuffix)
+				} else {
+					fix = instCreateText
+				}
 				result.FormatNotices = append(result.FormatNotices, &Annotation{
 					Statement: fsStmt,
 					Summary:   "SQL statement should be reformatted",
+					Fix:       fix,
 				})
 			}
 		}
 	}
 
-	for problemName, severity := range opts.ProblemSeverity {
-		annotations := problems[problemName](schema, logicalSchema, opts)
-		for _, a := range annotations {
-			a.Problem = problemName
-			if opts.ShouldIgnore(a.Statement.ObjectKey()) {
-				result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping %s because ignore-table='%s'", a.Statement.ObjectKey(), opts.IgnoreTable))
-			} else if severity == SeverityWarning {
-				result.Warnings = append(result.Warnings, a)
-			} else {
-				result.Errors = append(result.Errors, a)
+	return schema, result, suppressions
+}
+
+// CheckProtected compares schemas, previously obtained from LintDir(dir), to
+// the corresponding real schemas on instance. Any protected table or column
+// (see options protect-table and protect-column) that would be dropped or
+// destructively modified by pushing dir to instance results in an error
+// annotation. Tables and columns that do not exist on instance are not
+// considered.
+func CheckProtected(dir *fs.Dir, instance *tengo.Instance, schemas map[string]*tengo.Schema) *Result {
+	opts, err := OptionsForDir(dir)
+	if err != nil {
+		return BadConfigResult(dir, err)
+	}
+	result := &Result{}
+	if opts.ProtectTable == nil && opts.ProtectColumn == nil {
+		return result
+	}
+	protection := util.Protection{Table: opts.ProtectTable, Column: opts.ProtectColumn}
+	mods := tengo.StatementModifiers{
+		AllowUnsafe: true, // only interested in protected objects here
+		Flavor:      instance.Flavor(),
+	}
+
+	for _, logicalSchema := range dir.LogicalSchemas {
+		fsSchema := schemas[schemaKey(dir, logicalSchema)]
+		if fsSchema == nil {
+			continue
+		}
+		schemaNames := []string{logicalSchema.Name}
+		if logicalSchema.Name == "" {
+			if schemaNames, err = dir.SchemaNames(instance); err != nil {
+				result.Exceptions = append(result.Exceptions, err)
+				continue
+			}
+		}
+		instSchemas, err := instance.SchemasByName(schemaNames...)
+		if err != nil {
+			result.Exceptions = append(result.Exceptions, err)
+			continue
+		}
+		for _, schemaName := range schemaNames {
+			instSchema, ok := instSchemas[schemaName]
+			if !ok {
+				continue // nothing to protect if the schema doesn't exist yet
+			}
+			diff := tengo.NewSchemaDiff(instSchema, fsSchema)
+			for _, td := range diff.TableDiffs {
+				err := protection.Check(td)
+				if err == nil {
+					continue
+				}
+				key := td.ObjectKey()
+				if opts.ShouldIgnore(key) {
+					result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping %s because ignore-table='%s'", key, opts.IgnoreTable))
+					continue
+				}
+				// For dropped tables, there is no statement in the filesystem to point
+				// to, so use the generated DROP instead
+				fsStmt := logicalSchema.Creates[key]
+				if fsStmt == nil {
+					stmt, _ := td.Statement(mods)
+					fsStmt = &fs.Statement{
+						Text:       stmt,
+						ObjectType: key.Type,
+						ObjectName: key.Name,
+					}
+				}
+				result.Errors = append(result.Errors, &Annotation{
+					Statement: fsStmt,
+					Summary:   "Protected table or column",
+					Message:   fmt.Sprintf("Pushing to %s %s would require a forbidden change. %s.", instance, schemaName, err),
+				})
 			}
 		}
 	}
+	result.SortByFile()
+	return result
+}
 
-	return schema, result
+// schemaKey returns the key used in Result.Schemas for the supplied logical
+// schema within dir.
+func schemaKey(dir *fs.Dir, logicalSchema *fs.LogicalSchema) string {
+	if logicalSchema.Name == "" {
+		return dir.Path
+	}
+	return fmt.Sprintf("%s:%s", dir.Path, logicalSchema.Name)
 }
@@ -2,6 +2,7 @@ package linter
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -174,6 +175,28 @@ func TestLintDirExceptions(t *testing.T) {
 	}
 }
 
+func TestResultFilter(t *testing.T) {
+	stmtA := &fs.Statement{File: "a.sql"}
+	stmtB := &fs.Statement{File: "b.sql"}
+	result := &Result{
+		Errors:        []*Annotation{{Statement: stmtA}, {Statement: stmtB}},
+		Warnings:      []*Annotation{{Statement: stmtB}},
+		FormatNotices: []*Annotation{{Statement: stmtA}},
+	}
+	result.Filter(func(a *Annotation) bool {
+		return a.Statement.File == "a.sql"
+	})
+	if len(result.Errors) != 1 || result.Errors[0].Statement != stmtA {
+		t.Errorf("Unexpected errors after filtering: %+v", result.Errors)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("Unexpected warnings after filtering: %+v", result.Warnings)
+	}
+	if len(result.FormatNotices) != 1 {
+		t.Errorf("Unexpected format notices after filtering: %+v", result.FormatNotices)
+	}
+}
+
 func getRawConfig(t *testing.T, cliArgs ...string) *mybase.Config {
 	cmd := mybase.NewCommand("lintertest", "", "", nil)
 	util.AddGlobalOptions(cmd)
@@ -194,3 +217,46 @@ func getDir(t *testing.T, dirPath string, cliArgs ...string) *fs.Dir {
 	}
 	return dir
 }
+
+func TestResultAddDeferred(t *testing.T) {
+	stmt := &fs.Statement{
+		Text:       "CREATE TABLE orders ( -- skeema:disable cross-schema-column-mismatch\n  user_id int unsigned\n)",
+		Type:       fs.StatementTypeCreate,
+		ObjectType: tengo.ObjectTypeTable,
+		ObjectName: "orders",
+	}
+	fs.NewTokenizedSQLFile(fs.SQLFile{}, []*fs.Statement{stmt})
+	suppressions := findSuppressions(&fs.LogicalSchema{
+		Creates: map[tengo.ObjectKey]*fs.Statement{stmt.ObjectKey(): stmt},
+	})
+	opts := Options{
+		ProblemSeverity: map[string]Severity{
+			"cross-schema-missing-table":   SeverityError,
+			"cross-schema-column-mismatch": SeverityWarning,
+		},
+	}
+
+	result := &Result{}
+	for _, problem := range []string{"cross-schema-missing-table", "cross-schema-column-mismatch", "plan-pk-change"} {
+		result.addDeferred(&Annotation{Statement: stmt, Problem: problem}, opts, suppressions)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Problem != "cross-schema-missing-table" {
+		t.Errorf("Unexpected errors: %+v", result.Errors)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("Expected suppressed and disabled problems to be omitted, instead found warnings %+v", result.Warnings)
+	}
+	if !suppressions[0].used {
+		t.Error("Expected suppression to be marked as used")
+	}
+
+	// Per-object overrides also apply
+	opts.SeverityOverrides = map[string][]SeverityOverride{
+		"cross-schema-missing-table": {{Pattern: regexp.MustCompile("^orders$"), Severity: SeverityIgnore}},
+	}
+	result = &Result{}
+	result.addDeferred(&Annotation{Statement: stmt, Problem: "cross-schema-missing-table"}, opts, suppressions)
+	if len(result.Errors)+len(result.Warnings) != 0 {
+		t.Errorf("Expected override to ignore annotation, instead found %+v %+v", result.Errors, result.Warnings)
+	}
+}
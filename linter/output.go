@@ -0,0 +1,256 @@
+package linter
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// OutputFormats lists the machine-readable formats supported by WriteResult.
+var OutputFormats = []string{"json", "sarif", "checkstyle", "github"}
+
+// outputEntry is a format-independent representation of an annotation, used
+// for generating machine-readable output.
+type outputEntry struct {
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Problem  string `json:"problem,omitempty"`
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Message  string `json:"message"`
+}
+
+// outputEntries converts all annotations in r to outputEntry values. File
+// paths are made relative to baseDir where possible.
+func outputEntries(r *Result, baseDir string) (entries []outputEntry) {
+	add := func(annotations []*Annotation, severity string) {
+		for _, a := range annotations {
+			entry := outputEntry{
+				Problem:  a.Problem,
+				Severity: severity,
+				Summary:  a.Summary,
+				Message:  a.Message,
+			}
+			if entry.Message == "" {
+				entry.Message = a.Summary
+			}
+			if a.Statement.File != "" {
+				entry.File = a.Statement.File
+				if rel, err := filepath.Rel(baseDir, entry.File); err == nil {
+					entry.File = rel
+				}
+				entry.File = filepath.ToSlash(entry.File)
+				entry.Line = a.LineNo()
+			}
+			entries = append(entries, entry)
+		}
+	}
+	add(r.Errors, "error")
+	add(r.Warnings, "warning")
+	add(r.FormatNotices, "notice")
+	return entries
+}
+
+// WriteResult writes all errors, warnings, and format notices in r to w, using
+// the supplied format, which must be one of the values in OutputFormats. File
+// paths are output relative to baseDir.
+func WriteResult(w io.Writer, r *Result, format, baseDir string) error {
+	entries := outputEntries(r, baseDir)
+	switch format {
+	case "json":
+		return writeJSON(w, entries)
+	case "sarif":
+		return writeSARIF(w, entries)
+	case "checkstyle":
+		return writeCheckstyle(w, entries)
+	case "github":
+		return writeGitHub(w, entries)
+	}
+	return fmt.Errorf("Unsupported output format %q", format)
+}
+
+func writeJSON(w io.Writer, entries []outputEntry) error {
+	if entries == nil {
+		entries = []outputEntry{}
+	}
+	output := struct {
+		Annotations []outputEntry `json:"annotations"`
+	}{entries}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(output)
+}
+
+// SARIF 2.1.0 types. Only the subset of the specification needed for lint
+// results is represented here.
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID string `json:"id"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId,omitempty"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations,omitempty"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine int `json:"startLine"`
+	}
+)
+
+func writeSARIF(w io.Writer, entries []outputEntry) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "skeema",
+			InformationURI: "https://www.skeema.io",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	seenRules := make(map[string]bool)
+	for _, entry := range entries {
+		result := sarifResult{
+			RuleID:  entry.Problem,
+			Level:   entry.Severity,
+			Message: sarifMessage{Text: entry.Message},
+		}
+		if entry.Severity == "notice" {
+			result.Level = "note"
+		}
+		if entry.Problem != "" && !seenRules[entry.Problem] {
+			seenRules[entry.Problem] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: entry.Problem})
+		}
+		if entry.File != "" {
+			loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: entry.File},
+			}}
+			if entry.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: entry.Line}
+			}
+			result.Locations = []sarifLocation{loc}
+		}
+		run.Results = append(run.Results, result)
+	}
+	output := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(output)
+}
+
+// Checkstyle XML types.
+type (
+	checkstyleOutput struct {
+		XMLName xml.Name         `xml:"checkstyle"`
+		Version string           `xml:"version,attr"`
+		Files   []checkstyleFile `xml:"file"`
+	}
+	checkstyleFile struct {
+		Name   string            `xml:"name,attr"`
+		Errors []checkstyleError `xml:"error"`
+	}
+	checkstyleError struct {
+		Line     int    `xml:"line,attr,omitempty"`
+		Severity string `xml:"severity,attr"`
+		Message  string `xml:"message,attr"`
+		Source   string `xml:"source,attr"`
+	}
+)
+
+func writeCheckstyle(w io.Writer, entries []outputEntry) error {
+	output := checkstyleOutput{Version: "4.3"}
+	fileIndex := make(map[string]int)
+	for _, entry := range entries {
+		n, ok := fileIndex[entry.File]
+		if !ok {
+			n = len(output.Files)
+			fileIndex[entry.File] = n
+			output.Files = append(output.Files, checkstyleFile{Name: entry.File})
+		}
+		cerr := checkstyleError{
+			Line:     entry.Line,
+			Severity: entry.Severity,
+			Message:  entry.Message,
+			Source:   "skeema",
+		}
+		if entry.Severity == "notice" {
+			cerr.Severity = "info"
+		}
+		if entry.Problem != "" {
+			cerr.Source = "skeema." + entry.Problem
+		}
+		output.Files[n].Errors = append(output.Files[n].Errors, cerr)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(output); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeGitHub outputs GitHub Actions workflow commands, which cause annotations
+// to be displayed on the corresponding files and lines.
+func writeGitHub(w io.Writer, entries []outputEntry) error {
+	dataEscaper := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	propEscaper := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+	for _, entry := range entries {
+		var props []string
+		if entry.File != "" {
+			props = append(props, "file="+propEscaper.Replace(entry.File))
+			if entry.Line > 0 {
+				props = append(props, fmt.Sprintf("line=%d", entry.Line))
+			}
+		}
+		title := entry.Summary
+		if entry.Problem != "" {
+			title = fmt.Sprintf("%s (%s)", title, entry.Problem)
+		}
+		props = append(props, "title="+propEscaper.Replace(title))
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", entry.Severity, strings.Join(props, ","), dataEscaper.Replace(entry.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
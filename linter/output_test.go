@@ -0,0 +1,138 @@
+package linter
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func outputTestResult() *Result {
+	stmt := &fs.Statement{
+		File:       "/base/sub/foo.sql",
+		LineNo:     3,
+		Text:       "CREATE TABLE foo (\n  a float\n);\n",
+		Type:       fs.StatementTypeCreate,
+		ObjectType: tengo.ObjectTypeTable,
+		ObjectName: "foo",
+	}
+	return &Result{
+		Errors: []*Annotation{
+			{Statement: stmt, LineOffset: 1, Problem: "has-float", Summary: "Column using FLOAT or DOUBLE", Message: "Column a, uses: float\n100%"},
+		},
+		Warnings: []*Annotation{
+			{Statement: stmt, Problem: "no-pk", Summary: "No primary key", Message: "Table foo does not define a PRIMARY KEY"},
+		},
+		FormatNotices: []*Annotation{
+			{Statement: stmt, Summary: "SQL statement should be reformatted"},
+		},
+	}
+}
+
+func TestWriteResultJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteResult(&buf, outputTestResult(), "json", "/base"); err != nil {
+		t.Fatalf("Unexpected error from WriteResult: %s", err)
+	}
+	var output struct {
+		Annotations []outputEntry `json:"annotations"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("Unable to parse JSON output: %s", err)
+	}
+	expected := []outputEntry{
+		{File: "sub/foo.sql", Line: 4, Problem: "has-float", Severity: "error", Summary: "Column using FLOAT or DOUBLE", Message: "Column a, uses: float\n100%"},
+		{File: "sub/foo.sql", Line: 3, Problem: "no-pk", Severity: "warning", Summary: "No primary key", Message: "Table foo does not define a PRIMARY KEY"},
+		{File: "sub/foo.sql", Line: 3, Severity: "notice", Summary: "SQL statement should be reformatted", Message: "SQL statement should be reformatted"},
+	}
+	if len(output.Annotations) != len(expected) {
+		t.Fatalf("Expected %d annotations, instead found %d", len(expected), len(output.Annotations))
+	}
+	for n := range expected {
+		if output.Annotations[n] != expected[n] {
+			t.Errorf("Annotation[%d]: expected %+v, found %+v", n, expected[n], output.Annotations[n])
+		}
+	}
+
+	// Empty result should output an empty array, not null
+	buf.Reset()
+	if err := WriteResult(&buf, &Result{}, "json", "/base"); err != nil {
+		t.Fatalf("Unexpected error from WriteResult: %s", err)
+	}
+	if !strings.Contains(buf.String(), `"annotations": []`) {
+		t.Errorf("Unexpected output for empty result: %s", buf.String())
+	}
+}
+
+func TestWriteResultSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteResult(&buf, outputTestResult(), "sarif", "/base"); err != nil {
+		t.Fatalf("Unexpected error from WriteResult: %s", err)
+	}
+	var output sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("Unable to parse SARIF output: %s", err)
+	}
+	if output.Version != "2.1.0" || len(output.Runs) != 1 {
+		t.Fatalf("Unexpected SARIF output: %s", buf.String())
+	}
+	run := output.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 || run.Tool.Driver.Rules[0].ID != "has-float" || run.Tool.Driver.Rules[1].ID != "no-pk" {
+		t.Errorf("Unexpected rules in SARIF output: %+v", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 3 {
+		t.Fatalf("Expected 3 results, instead found %d", len(run.Results))
+	}
+	first := run.Results[0]
+	if first.RuleID != "has-float" || first.Level != "error" || first.Locations[0].PhysicalLocation.ArtifactLocation.URI != "sub/foo.sql" || first.Locations[0].PhysicalLocation.Region.StartLine != 4 {
+		t.Errorf("Unexpected first result: %+v", first)
+	}
+	if run.Results[2].Level != "note" || run.Results[2].RuleID != "" {
+		t.Errorf("Unexpected format notice result: %+v", run.Results[2])
+	}
+}
+
+func TestWriteResultCheckstyle(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteResult(&buf, outputTestResult(), "checkstyle", "/base"); err != nil {
+		t.Fatalf("Unexpected error from WriteResult: %s", err)
+	}
+	var output checkstyleOutput
+	if err := xml.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("Unable to parse Checkstyle output: %s", err)
+	}
+	if len(output.Files) != 1 || output.Files[0].Name != "sub/foo.sql" || len(output.Files[0].Errors) != 3 {
+		t.Fatalf("Unexpected Checkstyle output: %s", buf.String())
+	}
+	expected := []checkstyleError{
+		{Line: 4, Severity: "error", Message: "Column a, uses: float\n100%", Source: "skeema.has-float"},
+		{Line: 3, Severity: "warning", Message: "Table foo does not define a PRIMARY KEY", Source: "skeema.no-pk"},
+		{Line: 3, Severity: "info", Message: "SQL statement should be reformatted", Source: "skeema"},
+	}
+	for n := range expected {
+		if actual := output.Files[0].Errors[n]; actual != expected[n] {
+			t.Errorf("Error[%d]: expected %+v, found %+v", n, expected[n], actual)
+		}
+	}
+}
+
+func TestWriteResultGitHub(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteResult(&buf, outputTestResult(), "github", "/base"); err != nil {
+		t.Fatalf("Unexpected error from WriteResult: %s", err)
+	}
+	expected := "::error file=sub/foo.sql,line=4,title=Column using FLOAT or DOUBLE (has-float)::Column a, uses: float%0A100%25\n" +
+		"::warning file=sub/foo.sql,line=3,title=No primary key (no-pk)::Table foo does not define a PRIMARY KEY\n" +
+		"::notice file=sub/foo.sql,line=3,title=SQL statement should be reformatted::SQL statement should be reformatted\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected output from WriteResult:\n%s\nExpected:\n%s", buf.String(), expected)
+	}
+
+	if err := WriteResult(&buf, outputTestResult(), "invalid", "/base"); err == nil {
+		t.Error("Expected error from WriteResult with invalid format, but err was nil")
+	}
+}
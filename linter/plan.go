@@ -0,0 +1,212 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+// planRisk describes an operational risk in a generated ALTER TABLE.
+type planRisk struct {
+	problem    string
+	summary    string
+	message    string
+	lineOffset int
+	largeOnly  bool // if true, only a risk if the table is at least Options.LargeTableSize
+}
+
+// CheckPlan compares schemas, previously obtained from LintDir(dir), to the
+// corresponding real schemas on instance. Any ALTER TABLE that would be
+// generated by pushing dir to instance is examined for operational risks, each
+// resulting in an annotation if the corresponding plan-* problem is enabled via
+// the errors or warnings options and not suppressed by a skeema:disable
+// comment. If the dir's logical schema doesn't specify a schema name,
+// dirSchemaNames is used as the list of schema names on instance. Tables that
+// do not exist on instance are not considered.
+func CheckPlan(dir *fs.Dir, instance *tengo.Instance, dirSchemaNames []string, schemas map[string]*tengo.Schema) *Result {
+	opts, err := OptionsForDir(dir)
+	if err != nil {
+		return BadConfigResult(dir, err)
+	}
+	result := &Result{}
+	for _, logicalSchema := range dir.LogicalSchemas {
+		fsSchema := schemas[schemaKey(dir, logicalSchema)]
+		if fsSchema == nil {
+			continue
+		}
+		schemaNames := []string{logicalSchema.Name}
+		if logicalSchema.Name == "" {
+			schemaNames = dirSchemaNames
+		}
+		instSchemas, err := instance.SchemasByName(schemaNames...)
+		if err != nil {
+			result.Exceptions = append(result.Exceptions, err)
+			continue
+		}
+		suppressions := findSuppressions(logicalSchema)
+		for _, schemaName := range schemaNames {
+			instSchema, ok := instSchemas[schemaName]
+			if !ok {
+				continue // new schema, so all tables will be created rather than altered
+			}
+			diff := tengo.NewSchemaDiff(instSchema, fsSchema)
+			for _, td := range diff.TableDiffs {
+				key := td.ObjectKey()
+				fsStmt := logicalSchema.Creates[key]
+				if td.DiffType() != tengo.DiffTypeAlter || fsStmt == nil {
+					continue
+				} else if opts.ShouldIgnore(key) {
+					result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping %s because ignore-table='%s'", key, opts.IgnoreTable))
+					continue
+				}
+				var size int64 = -1
+				for _, risk := range alterRisks(td.From, td.To, fsStmt) {
+					if _, ok := opts.SeverityFor(risk.problem, key); !ok {
+						continue // avoid querying table size for problems that aren't enabled
+					}
+					if risk.largeOnly {
+						if size < 0 {
+							if size, err = instance.TableSize(schemaName, td.From.Name); err != nil {
+								result.Exceptions = append(result.Exceptions, err)
+								break
+							}
+						}
+						if uint64(size) < opts.LargeTableSize {
+							continue
+						}
+						risk.message = fmt.Sprintf("%s Table size is currently %d bytes.", risk.message, size)
+					}
+					result.addDeferred(&Annotation{
+						Statement:  fsStmt,
+						LineOffset: risk.lineOffset,
+						Problem:    risk.problem,
+						Summary:    risk.summary,
+						Message:    fmt.Sprintf("Pushing to %s %s: %s", instance, schemaName, risk.message),
+					}, opts, suppressions)
+				}
+			}
+		}
+	}
+	result.SortByFile()
+	return result
+}
+
+// ChangedObjects compares schemas, previously obtained from LintDir(dir), to
+// the corresponding real schemas on instance, returning the set of object keys
+// in dir which would be created or altered by pushing dir to instance. If the
+// dir's logical schema doesn't specify a schema name, dirSchemaNames is used as
+// the list of schema names on instance.
+func ChangedObjects(dir *fs.Dir, instance *tengo.Instance, dirSchemaNames []string, schemas map[string]*tengo.Schema) (map[tengo.ObjectKey]bool, error) {
+	changed := make(map[tengo.ObjectKey]bool)
+	for _, logicalSchema := range dir.LogicalSchemas {
+		fsSchema := schemas[schemaKey(dir, logicalSchema)]
+		if fsSchema == nil {
+			continue
+		}
+		schemaNames := []string{logicalSchema.Name}
+		if logicalSchema.Name == "" {
+			schemaNames = dirSchemaNames
+		}
+		instSchemas, err := instance.SchemasByName(schemaNames...)
+		if err != nil {
+			return nil, err
+		}
+		for _, schemaName := range schemaNames {
+			// If the schema doesn't exist yet, instSchema is nil, and the diff will
+			// consist of creating every object
+			diff := tengo.NewSchemaDiff(instSchemas[schemaName], fsSchema)
+			for _, od := range diff.ObjectDiffs() {
+				if dt := od.DiffType(); dt == tengo.DiffTypeCreate || dt == tengo.DiffTypeAlter {
+					changed[od.ObjectKey()] = true
+				}
+			}
+		}
+	}
+	return changed, nil
+}
+
+var rePrimaryKeyLine = regexp.MustCompile(`(?im)^\s*PRIMARY KEY`)
+
+// alterRisks returns operational risks in altering table from to match table
+// to. stmt is used for determining line offsets.
+func alterRisks(from, to *tengo.Table, stmt *fs.Statement) (risks []planRisk) {
+	// Changing the primary key
+	pkChanged := !from.PrimaryKey.Equivalent(to.PrimaryKey)
+	if pkChanged {
+		risks = append(risks, planRisk{
+			problem:    "plan-pk-change",
+			summary:    "Primary key changed",
+			message:    fmt.Sprintf("Table %s will have its PRIMARY KEY changed, which rebuilds the table and changes the clustered index that all secondary indexes refer to.", tengo.EscapeIdentifier(to.Name)),
+			lineOffset: findFirstLineOffset(rePrimaryKeyLine, stmt.Text),
+		})
+	}
+
+	// Adding NOT NULL columns without a default, and changes to existing columns
+	// that require a table copy or convert character sets
+	fromCols := from.ColumnsByName()
+	var copyReasons []string
+	for _, col := range to.Columns {
+		fromCol, existed := fromCols[col.Name]
+		if !existed {
+			if !col.Nullable && col.Default.Null && !col.AutoIncrement {
+				risks = append(risks, planRisk{
+					problem:    "plan-not-null-no-default",
+					summary:    "NOT NULL column added without default",
+					message:    fmt.Sprintf("Column %s will be added as NOT NULL without a DEFAULT. Existing rows will receive an implicit default value, and any application INSERTs omitting this column will fail in strict mode.", tengo.EscapeIdentifier(col.Name)),
+					lineOffset: columnLineOffset(col, stmt),
+				})
+			}
+			continue
+		}
+		if fromCol.CharSet != "" && col.CharSet != "" && fromCol.CharSet != col.CharSet {
+			risks = append(risks, planRisk{
+				problem:    "plan-charset-conversion",
+				summary:    "Character set conversion on large table",
+				message:    fmt.Sprintf("Column %s will be converted from character set %s to %s, which requires copying and converting all existing data.", tengo.EscapeIdentifier(col.Name), fromCol.CharSet, col.CharSet),
+				lineOffset: columnLineOffset(col, stmt),
+				largeOnly:  true,
+			})
+		} else if fromCol.TypeInDB != col.TypeInDB {
+			copyReasons = append(copyReasons, fmt.Sprintf("column %s type change", tengo.EscapeIdentifier(col.Name)))
+		} else if fromCol.Nullable != col.Nullable {
+			copyReasons = append(copyReasons, fmt.Sprintf("column %s nullability change", tengo.EscapeIdentifier(col.Name)))
+		}
+	}
+	if from.Engine != to.Engine {
+		copyReasons = append(copyReasons, "storage engine change")
+	}
+	if pkChanged {
+		copyReasons = append(copyReasons, "primary key change")
+	}
+	if len(copyReasons) > 0 {
+		risks = append(risks, planRisk{
+			problem:   "plan-copy-alter",
+			summary:   "ALTER requires table copy on large table",
+			message:   fmt.Sprintf("ALTER TABLE %s will likely use ALGORITHM=COPY due to %s, blocking writes for the duration of the copy unless an external online schema change tool is used.", tengo.EscapeIdentifier(to.Name), strings.Join(copyReasons, ", ")),
+			largeOnly: true,
+		})
+	}
+
+	// Dropping an index that is needed by a foreign key
+	toIndexes := to.SecondaryIndexesByName()
+	for _, idx := range from.SecondaryIndexes {
+		if toIdx, ok := toIndexes[idx.Name]; ok && toIdx.Equivalent(idx) {
+			continue
+		}
+		for _, fk := range to.ForeignKeys {
+			if !columnsArePrefix(fk.Columns, idx) || hasExplicitIndexForFK(to, fk, stmt) {
+				continue
+			}
+			risks = append(risks, planRisk{
+				problem:    "plan-drop-fk-index",
+				summary:    "Dropped index backs a foreign key",
+				message:    fmt.Sprintf("Index %s will be dropped or modified, but foreign key %s requires an index on its columns, and no other index in the new definition supports it. The ALTER will fail, or MySQL will have to create an index implicitly.", tengo.EscapeIdentifier(idx.Name), tengo.EscapeIdentifier(fk.Name)),
+				lineOffset: foreignKeyLineOffset(fk, stmt),
+			})
+		}
+	}
+	return risks
+}
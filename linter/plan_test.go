@@ -0,0 +1,81 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestAlterRisks(t *testing.T) {
+	makeTable := func() *tengo.Table {
+		cols := []*tengo.Column{
+			{Name: "id", TypeInDB: "int(10) unsigned", AutoIncrement: true, Default: tengo.ColumnDefaultNull},
+			{Name: "parent_id", TypeInDB: "int(10) unsigned", Default: tengo.ColumnDefaultNull},
+			{Name: "name", TypeInDB: "varchar(30)", Nullable: true, CharSet: "latin1", Collation: "latin1_swedish_ci", Default: tengo.ColumnDefaultNull},
+		}
+		return &tengo.Table{
+			Name:             "t",
+			Engine:           "InnoDB",
+			CharSet:          "latin1",
+			Columns:          cols,
+			PrimaryKey:       &tengo.Index{Name: "PRIMARY", Columns: cols[0:1], SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+			SecondaryIndexes: []*tengo.Index{{Name: "parent", Columns: cols[1:2], SubParts: []uint16{0}}},
+			ForeignKeys:      []*tengo.ForeignKey{{Name: "fk_parent", Columns: cols[1:2], ReferencedTableName: "t", ReferencedColumnNames: []string{"id"}}},
+		}
+	}
+	stmt := &fs.Statement{
+		Text: "CREATE TABLE `t` (\n" +
+			"  `id` int(10) unsigned NOT NULL AUTO_INCREMENT,\n" +
+			"  `parent_id` int(10) unsigned NOT NULL,\n" +
+			"  `name` varchar(30) DEFAULT NULL,\n" +
+			"  `created_at` datetime NOT NULL,\n" +
+			"  PRIMARY KEY (`id`),\n" +
+			"  CONSTRAINT `fk_parent` FOREIGN KEY (`parent_id`) REFERENCES `t` (`id`)\n" +
+			") ENGINE=InnoDB DEFAULT CHARSET=latin1;\n",
+	}
+	assertRisks := func(from, to *tengo.Table, expected map[string]int) {
+		t.Helper()
+		risks := alterRisks(from, to, stmt)
+		if len(risks) != len(expected) {
+			t.Errorf("Expected %d risks, instead found %d: %+v", len(expected), len(risks), risks)
+			return
+		}
+		for _, risk := range risks {
+			if lineOffset, ok := expected[risk.problem]; !ok {
+				t.Errorf("Unexpected risk %s: %s", risk.problem, risk.message)
+			} else if lineOffset >= 0 && risk.lineOffset != lineOffset {
+				t.Errorf("Expected risk %s to have line offset %d, instead found %d", risk.problem, lineOffset, risk.lineOffset)
+			}
+		}
+	}
+
+	// No changes: no risks
+	assertRisks(makeTable(), makeTable(), map[string]int{})
+
+	// Add a NOT NULL column without default, and drop the index backing the FK
+	to := makeTable()
+	to.Columns = append(to.Columns, &tengo.Column{Name: "created_at", TypeInDB: "datetime", Default: tengo.ColumnDefaultNull})
+	to.SecondaryIndexes = nil
+	assertRisks(makeTable(), to, map[string]int{"plan-not-null-no-default": 4, "plan-drop-fk-index": 6})
+
+	// The index implicitly created by the server for the FK does not count, since
+	// it is not defined in the CREATE TABLE
+	to = makeTable()
+	to.SecondaryIndexes = []*tengo.Index{{Name: "fk_parent", Columns: to.Columns[1:2], SubParts: []uint16{0}}}
+	assertRisks(makeTable(), to, map[string]int{"plan-drop-fk-index": 6})
+
+	// Nullable or defaulted new columns are fine; dropping the index is fine if
+	// another index supports the FK
+	to = makeTable()
+	to.Columns = append(to.Columns, &tengo.Column{Name: "created_at", TypeInDB: "datetime", Default: tengo.ColumnDefaultValue("2000-01-01 00:00:00")})
+	to.SecondaryIndexes = []*tengo.Index{{Name: "parent_name", Columns: to.Columns[1:3], SubParts: []uint16{0, 0}}}
+	assertRisks(makeTable(), to, map[string]int{})
+
+	// Change PK, convert charset, and modify a column type
+	to = makeTable()
+	to.PrimaryKey = &tengo.Index{Name: "PRIMARY", Columns: to.Columns[0:2], SubParts: []uint16{0, 0}, PrimaryKey: true, Unique: true}
+	to.Columns[2].CharSet, to.Columns[2].Collation = "utf8mb4", "utf8mb4_general_ci"
+	to.Columns[1].TypeInDB = "bigint(20) unsigned"
+	assertRisks(makeTable(), to, map[string]int{"plan-pk-change": 5, "plan-charset-conversion": 3, "plan-copy-alter": -1})
+}
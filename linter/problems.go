@@ -2,11 +2,14 @@ package linter
 
 import (
 	"fmt"
+	"math"
 	"regexp"
 	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/tengo"
 )
 
@@ -26,6 +29,53 @@ func init() {
 		"no-pk":       noPKDetector,
 		"bad-charset": badCharsetDetector,
 		"bad-engine":  badEngineDetector,
+		"dupe-index":  dupeIndexDetector,
+
+		"has-fk":           hasFKDetector,
+		"fk-missing-index": fkMissingIndexDetector,
+		"fk-type-mismatch": fkTypeMismatchDetector,
+		"fk-cross-schema":  fkCrossSchemaDetector,
+
+		"has-float":     hasFloatDetector,
+		"has-enum":      hasEnumDetector,
+		"has-timestamp": hasTimestampDetector,
+		"pk-type":       pkTypeDetector,
+
+		"auto-inc-capacity": autoIncCapacityDetector,
+		"bad-name":          badNameDetector,
+
+		"bad-definer":            badDefinerDetector,
+		"security-definer":       securityDefinerDetector,
+		"non-deterministic-func": nonDeterministicFuncDetector,
+		"bad-sql-mode":           badSQLModeDetector,
+
+		"missing-comment": missingCommentDetector,
+
+		"too-many-columns": tooManyColumnsDetector,
+		"too-many-indexes": tooManyIndexesDetector,
+		"index-too-long":   indexTooLongDetector,
+		"row-too-large":    rowTooLargeDetector,
+		"varchar-too-long": varcharTooLongDetector,
+
+		// These require a live database, and are detected by CheckPlan
+		"plan-copy-alter":          deferredDetector,
+		"plan-charset-conversion":  deferredDetector,
+		"plan-not-null-no-default": deferredDetector,
+		"plan-pk-change":           deferredDetector,
+		"plan-drop-fk-index":       deferredDetector,
+
+		// These require comparing multiple dirs, and are detected by
+		// CheckConsistency
+		"cross-schema-missing-table":   deferredDetector,
+		"cross-schema-column-mismatch": deferredDetector,
+
+		// These require a workspace of a different flavor, and are detected by
+		// CheckUpgrade
+		"upgrade-sql-error":        deferredDetector,
+		"upgrade-canonical-change": deferredDetector,
+		"upgrade-reserved-word":    deferredDetector,
+		"upgrade-removed-feature":  deferredDetector,
+		"upgrade-collation-change": deferredDetector,
 	}
 }
 
@@ -66,13 +116,21 @@ func badCharsetDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, o
 		} else if len(opts.AllowedCharSets) > 1 && len(opts.AllowedCharSets) <= 5 {
 			allowedList = fmt.Sprintf(" The following character sets are permitted: %s.", strings.Join(opts.AllowedCharSets, ", "))
 		}
-		if charSet == "utf8" && isAllowed("utf8mb4", opts.AllowedCharSets) {
+		if isUTF8MB3(charSet) && isAllowed("utf8mb4", opts.AllowedCharSets) {
 			moreInfo = "\nTo permit storage of all valid UTF-8 characters, use the utf8mb4 character set instead of the legacy utf8 character set."
 		} else if charSet == "binary" {
 			moreInfo = "\nUsing equivalent binary column types (e.g. BINARY, VARBINARY, BLOB) is preferred for readability."
 		}
 		return fmt.Sprintf("%s is using %s %s, which is not listed in option allow-charset.%s%s", subject, using, charSet, allowedList, moreInfo)
 	}
+	// Legacy utf8 can be fixed by converting the whole table to utf8mb4, so that
+	// each annotation for the same table has the same fix
+	makeFix := func(table *tengo.Table, charSet string) string {
+		if !isUTF8MB3(charSet) || !isAllowed("utf8mb4", opts.AllowedCharSets) {
+			return ""
+		}
+		return utf8mb4Fix(table, opts.Flavor)
+	}
 
 	for _, table := range schema.Tables {
 		// Check the table's default charset
@@ -85,6 +143,7 @@ func badCharsetDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, o
 				LineOffset: findLastLineOffset(re, stmt.Text),
 				Summary:    "Character set not permitted",
 				Message:    makeMessage(table, nil),
+				Fix:        makeFix(table, table.CharSet),
 			})
 			continue // if a table's default charset isn't allowed, don't generate col-level annotations too
 		}
@@ -100,6 +159,7 @@ func badCharsetDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, o
 					LineOffset: findFirstLineOffset(re, stmt.Text),
 					Summary:    "Character set not permitted",
 					Message:    makeMessage(table, col),
+					Fix:        makeFix(table, col.CharSet),
 				})
 			}
 		}
@@ -120,18 +180,785 @@ func badEngineDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, op
 			} else if len(opts.AllowedEngines) > 1 && len(opts.AllowedEngines) <= 5 {
 				message = fmt.Sprintf("%s The following storage engines are permitted: %s.", message, strings.Join(opts.AllowedEngines, ", "))
 			}
+			var fix string
+			if strings.EqualFold(table.Engine, "MyISAM") && isAllowed("InnoDB", opts.AllowedEngines) {
+				fix = innoDBFix(table, opts.Flavor)
+			}
 			results = append(results, &Annotation{
 				Statement:  stmt,
 				LineOffset: findFirstLineOffset(re, stmt.Text),
 				Summary:    "Storage engine not permitted",
 				Message:    message,
+				Fix:        fix,
+			})
+		}
+	}
+
+	return results
+}
+
+func dupeIndexDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, _ Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}
+		stmt := logicalSchema.Creates[key]
+		for n, idx := range table.SecondaryIndexes {
+			other := redundantIndexFor(table, n)
+			if other == nil {
+				continue
+			}
+			var otherDesc, relation string
+			if other.PrimaryKey {
+				otherDesc = "the PRIMARY KEY"
+			} else {
+				otherDesc = fmt.Sprintf("index %s", other.Name)
+			}
+			if len(idx.Columns) == len(other.Columns) {
+				relation = "a duplicate of"
+			} else {
+				relation = "redundant to"
+			}
+			message := fmt.Sprintf("Index %s of table %s is %s %s, which already covers its columns. Consider dropping index %s.", idx.Name, table.Name, relation, otherDesc, idx.Name)
+			for _, fk := range table.ForeignKeys {
+				if columnsArePrefix(fk.Columns, idx) {
+					message += fmt.Sprintf("\nForeign key %s can use %s instead.", fk.Name, otherDesc)
+					break
+				}
+			}
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: findFirstLineOffset(indexDefinitionRegexp(idx), stmt.Text),
+				Summary:    "Redundant index",
+				Message:    message,
+			})
+		}
+	}
+	return results
+}
+
+// redundantIndexFor returns an index of table that makes the secondary index
+// at position n of table.SecondaryIndexes unnecessary, or nil if there is no
+// such index. A unique index is only considered unnecessary if another unique
+// index (or the primary key) has exactly the same columns, since otherwise the
+// uniqueness constraint would be lost. When two secondary indexes are exact
+// duplicates, only the later one is considered unnecessary.
+func redundantIndexFor(table *tengo.Table, n int) *tengo.Index {
+	idx := table.SecondaryIndexes[n]
+	if idx.RedundantTo(table.PrimaryKey) && (!idx.Unique || len(idx.Columns) == len(table.PrimaryKey.Columns)) {
+		return table.PrimaryKey
+	}
+	for otherN, other := range table.SecondaryIndexes {
+		if otherN == n || !idx.RedundantTo(other) {
+			continue
+		} else if idx.Unique && len(idx.Columns) < len(other.Columns) {
+			continue
+		} else if otherN > n && other.RedundantTo(idx) {
+			continue
+		}
+		return other
+	}
+	return nil
+}
+
+// columnsArePrefix returns true if cols are a left prefix of idx's columns,
+// without any sub-parts. This is the requirement for an index to be usable by
+// a foreign key.
+func columnsArePrefix(cols []*tengo.Column, idx *tengo.Index) bool {
+	if len(cols) > len(idx.Columns) {
+		return false
+	}
+	for n, col := range cols {
+		if col.Name != idx.Columns[n].Name || idx.SubParts[n] > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func hasFKDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, _ Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		for _, fk := range table.ForeignKeys {
+			stmt := logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}]
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: foreignKeyLineOffset(fk, stmt),
+				Summary:    "Foreign key present",
+				Message:    fmt.Sprintf("Table %s has foreign key %s referencing table %s. Foreign keys hurt write concurrency, and complicate online schema changes and sharding.", table.Name, fk.Name, fk.ReferencedTableName),
+			})
+		}
+	}
+	return results
+}
+
+func fkMissingIndexDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		stmt := logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}]
+		for _, fk := range table.ForeignKeys {
+			if hasExplicitIndexForFK(table, fk, stmt) {
+				continue
+			}
+			colNames := make([]string, len(fk.Columns))
+			for n, col := range fk.Columns {
+				colNames[n] = col.Name
+			}
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: foreignKeyLineOffset(fk, stmt),
+				Summary:    "Foreign key without index",
+				Message:    fmt.Sprintf("Foreign key %s of table %s uses columns (%s), which are not a left prefix of any index defined in the table. The server will implicitly create an index for the foreign key; define one explicitly instead.", fk.Name, table.Name, strings.Join(colNames, ", ")),
+				Fix:        fkIndexFix(table, fk, opts.Flavor),
+			})
+		}
+	}
+	return results
+}
+
+// hasExplicitIndexForFK returns true if table has an index (including the
+// primary key) whose leftmost columns are fk's columns. If no index supports a
+// foreign key, the server automatically creates one with the same name as the
+// constraint; such an index only counts if it was explicitly defined in stmt.
+func hasExplicitIndexForFK(table *tengo.Table, fk *tengo.ForeignKey, stmt *fs.Statement) bool {
+	for _, idx := range tableIndexes(table) {
+		if columnsArePrefix(fk.Columns, idx) && (idx.Name != fk.Name || indexDefinitionRegexp(idx).MatchString(stmt.Text)) {
+			return true
+		}
+	}
+	return false
+}
+
+func fkTypeMismatchDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, _ Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	tablesByName := schema.TablesByName()
+	for _, table := range schema.Tables {
+		stmt := logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}]
+		for _, fk := range table.ForeignKeys {
+			// Parent tables outside of this schema cannot be examined
+			if !sameSchema(fk.ReferencedSchemaName, schema, logicalSchema) {
+				continue
+			}
+			parent := tablesByName[fk.ReferencedTableName]
+			if parent == nil {
+				continue
+			}
+			parentCols := parent.ColumnsByName()
+			var mismatches []string
+			for n, col := range fk.Columns {
+				parentCol := parentCols[fk.ReferencedColumnNames[n]]
+				if parentCol == nil {
+					continue
+				}
+				// Integer display width has no bearing on foreign key compatibility
+				colType := reIntDisplayWidth.ReplaceAllString(col.TypeInDB, "$1")
+				parentColType := reIntDisplayWidth.ReplaceAllString(parentCol.TypeInDB, "$1")
+				if colType != parentColType {
+					mismatches = append(mismatches, fmt.Sprintf("column %s has type %s, but %s.%s has type %s", col.Name, col.TypeInDB, parent.Name, parentCol.Name, parentCol.TypeInDB))
+				} else if col.CharSet != parentCol.CharSet || col.Collation != parentCol.Collation {
+					mismatches = append(mismatches, fmt.Sprintf("column %s has collation %s, but %s.%s has collation %s", col.Name, col.Collation, parent.Name, parentCol.Name, parentCol.Collation))
+				}
+			}
+			if len(mismatches) > 0 {
+				results = append(results, &Annotation{
+					Statement:  stmt,
+					LineOffset: foreignKeyLineOffset(fk, stmt),
+					Summary:    "Foreign key column type mismatch",
+					Message:    fmt.Sprintf("Foreign key %s of table %s references columns of a different type: %s.", fk.Name, table.Name, strings.Join(mismatches, "; ")),
+				})
+			}
+		}
+	}
+	return results
+}
+
+func fkCrossSchemaDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, _ Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		for _, fk := range table.ForeignKeys {
+			if sameSchema(fk.ReferencedSchemaName, schema, logicalSchema) {
+				continue
+			}
+			stmt := logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}]
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: foreignKeyLineOffset(fk, stmt),
+				Summary:    "Cross-schema foreign key",
+				Message:    fmt.Sprintf("Foreign key %s of table %s references table %s in a different schema, %s. Cross-schema foreign keys cannot be validated or managed as a unit.", fk.Name, table.Name, fk.ReferencedTableName, fk.ReferencedSchemaName),
+			})
+		}
+	}
+	return results
+}
+
+func hasFloatDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	return columnTypeAnnotations(schema, logicalSchema, "Floating-point column", func(table *tengo.Table, col *tengo.Column) string {
+		if baseType := columnBaseType(col); baseType != "float" && baseType != "double" {
+			return ""
+		}
+		if columnMatches(col, opts.AllowFloat) {
+			return ""
+		}
+		return fmt.Sprintf("Column %s of table %s is using floating-point type %s, which only stores approximate values. Use DECIMAL for values that must be exact, such as money.", col.Name, table.Name, col.TypeInDB)
+	})
+}
+
+func hasEnumDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	return columnTypeAnnotations(schema, logicalSchema, "ENUM or SET column", func(table *tengo.Table, col *tengo.Column) string {
+		if baseType := columnBaseType(col); baseType != "enum" && baseType != "set" {
+			return ""
+		}
+		if columnMatches(col, opts.AllowEnum) {
+			return ""
+		}
+		return fmt.Sprintf("Column %s of table %s is using type %s. Changing the list of permitted values requires an ALTER TABLE; consider using a lookup table instead.", col.Name, table.Name, strings.ToUpper(columnBaseType(col)))
+	})
+}
+
+func hasTimestampDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	return columnTypeAnnotations(schema, logicalSchema, "TIMESTAMP column", func(table *tengo.Table, col *tengo.Column) string {
+		if columnBaseType(col) != "timestamp" {
+			return ""
+		}
+		if columnMatches(col, opts.AllowTimestamp) {
+			return ""
+		}
+		return fmt.Sprintf("Column %s of table %s is using type timestamp, which cannot store values after 2038-01-19. Consider using DATETIME instead.", col.Name, table.Name)
+	})
+}
+
+// columnMatches returns true if re is non-nil and matches the column's name.
+func columnMatches(col *tengo.Column, re *regexp.Regexp) bool {
+	return re != nil && re.MatchString(col.Name)
+}
+
+func pkTypeDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	return columnTypeAnnotations(schema, logicalSchema, "Primary key type not permitted", func(table *tengo.Table, col *tengo.Column) string {
+		if !col.AutoIncrement || table.PrimaryKey == nil || !columnsArePrefix([]*tengo.Column{col}, table.PrimaryKey) {
+			return ""
+		}
+		baseType, fullType := columnBaseType(col), columnBaseType(col)
+		if strings.Contains(col.TypeInDB, "unsigned") {
+			fullType += " unsigned"
+		}
+		if isAllowed(baseType, opts.AllowedPKTypes) || isAllowed(fullType, opts.AllowedPKTypes) {
+			return ""
+		}
+		message := fmt.Sprintf("Column %s of table %s is an auto-increment primary key using type %s, which is not listed in option allow-pk-type.", col.Name, table.Name, col.TypeInDB)
+		if len(opts.AllowedPKTypes) == 1 {
+			message = fmt.Sprintf("%s Only the %s type is permitted.", message, opts.AllowedPKTypes[0])
+		} else if len(opts.AllowedPKTypes) > 1 && len(opts.AllowedPKTypes) <= 5 {
+			message = fmt.Sprintf("%s The following types are permitted: %s.", message, strings.Join(opts.AllowedPKTypes, ", "))
+		}
+		return message
+	})
+}
+
+func autoIncCapacityDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, _ Options) []*Annotation {
+	return columnTypeAnnotations(schema, logicalSchema, "Auto-increment column type too small", func(table *tengo.Table, col *tengo.Column) string {
+		if !col.AutoIncrement {
+			return ""
+		}
+		max, ok := util.AutoIncrementMax(col)
+		if !ok || max >= math.MaxUint32 {
+			return ""
+		}
+		return fmt.Sprintf("Column %s of table %s is an auto-increment column using type %s, which can only store values up to %d. Consider using int unsigned or bigint unsigned instead.", col.Name, table.Name, col.TypeInDB, max)
+	})
+}
+
+// columnTypeAnnotations returns an annotation for each column in schema for
+// which makeMessage returns a non-empty string. Each annotation points to the
+// column's definition line, if it can be found.
+func columnTypeAnnotations(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, summary string, makeMessage func(*tengo.Table, *tengo.Column) string) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		for _, col := range table.Columns {
+			message := makeMessage(table, col)
+			if message == "" {
+				continue
+			}
+			stmt := logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}]
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: columnLineOffset(col, stmt),
+				Summary:    summary,
+				Message:    message,
+			})
+		}
+	}
+	return results
+}
+
+// columnBaseType returns col's type without any length, precision, value
+// list, or modifiers such as unsigned. For example, a column of type
+// "int(10) unsigned" has base type "int".
+func columnBaseType(col *tengo.Column) string {
+	baseType := strings.ToLower(col.TypeInDB)
+	if pos := strings.IndexAny(baseType, "( "); pos > -1 {
+		baseType = baseType[0:pos]
+	}
+	return baseType
+}
+
+// columnLineOffset returns the line offset of col's definition within stmt.
+// Column definitions are expected to begin their own line; if col's cannot be
+// found, 0 is returned.
+func columnLineOffset(col *tengo.Column, stmt *fs.Statement) int {
+	re := regexp.MustCompile(fmt.Sprintf("(?im)^[\\s(]*`?%s`?\\s+[a-z]", regexp.QuoteMeta(col.Name)))
+	return findFirstLineOffset(re, stmt.Text)
+}
+
+func badNameDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	disallowReserved := isAllowed("reserved-word", opts.NamingDisallow)
+	disallowMixedCase := isAllowed("mixed-case", opts.NamingDisallow)
+	check := func(objectType, name string, stmt *fs.Statement, lineOffset int) {
+		var reasons []string
+		if re := opts.NamePatterns[objectType]; re != nil && !re.MatchString(name) {
+			reasons = append(reasons, fmt.Sprintf("does not match option naming-%s", objectType))
+		}
+		if disallowReserved && isReservedWord(name, opts.Flavor) {
+			reasons = append(reasons, "is a reserved word")
+		}
+		if disallowMixedCase && strings.ToLower(name) != name && strings.ToUpper(name) != name {
+			reasons = append(reasons, "uses mixed case")
+		}
+		if len(reasons) > 0 {
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: lineOffset,
+				Summary:    "Naming convention violation",
+				Message:    fmt.Sprintf("The name of %s %s %s.", strings.Replace(objectType, "-", " ", -1), name, strings.Join(reasons, ", and ")),
+			})
+		}
+	}
+
+	for _, table := range schema.Tables {
+		stmt := logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}]
+		check("table", table.Name, stmt, 0)
+		for _, col := range table.Columns {
+			check("column", col.Name, stmt, columnLineOffset(col, stmt))
+		}
+
+		// Indexes and foreign keys may have names generated automatically by the
+		// server, which are only checked if they were explicitly specified.
+		for _, idx := range table.SecondaryIndexes {
+			re := indexDefinitionRegexp(idx)
+			if re.MatchString(stmt.Text) {
+				check("index", idx.Name, stmt, findFirstLineOffset(re, stmt.Text))
+			}
+		}
+		for _, fk := range table.ForeignKeys {
+			re := foreignKeyRegexp(fk)
+			if re.MatchString(stmt.Text) {
+				check("foreign-key", fk.Name, stmt, findFirstLineOffset(re, stmt.Text))
+			}
+		}
+	}
+	for _, routine := range schema.Routines {
+		stmt := logicalSchema.Creates[tengo.ObjectKey{Type: routine.Type, Name: routine.Name}]
+		check(string(routine.Type), routine.Name, stmt, 0)
+	}
+	return results
+}
+
+func badDefinerDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	re := regexp.MustCompile(`(?i)definer\s*=`)
+	return routineAnnotations(schema, logicalSchema, "Definer not permitted", re, func(routine *tengo.Routine) string {
+		for _, allowed := range opts.AllowedDefiners {
+			if definerMatches(routine.Definer, allowed) {
+				return ""
+			}
+		}
+		message := fmt.Sprintf("%s %s has definer %s, which is not listed in option allow-definer.", strings.Title(string(routine.Type)), routine.Name, routine.Definer)
+		if len(opts.AllowedDefiners) == 1 {
+			message = fmt.Sprintf("%s Only definer %s is permitted.", message, opts.AllowedDefiners[0])
+		} else if len(opts.AllowedDefiners) > 1 && len(opts.AllowedDefiners) <= 5 {
+			message = fmt.Sprintf("%s The following definers are permitted: %s.", message, strings.Join(opts.AllowedDefiners, ", "))
+		}
+		return message
+	})
+}
+
+func securityDefinerDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, _ Options) []*Annotation {
+	re := regexp.MustCompile(`(?i)sql\s+security\s+definer`)
+	return routineAnnotations(schema, logicalSchema, "SQL SECURITY DEFINER", re, func(routine *tengo.Routine) string {
+		if routine.SecurityType != "DEFINER" {
+			return ""
+		}
+		message := fmt.Sprintf("%s %s uses SQL SECURITY DEFINER, meaning it runs with the privileges of its definer %s rather than those of the caller.", strings.Title(string(routine.Type)), routine.Name, routine.Definer)
+		if !re.MatchString(logicalSchema.Creates[tengo.ObjectKey{Type: routine.Type, Name: routine.Name}].Text) {
+			message += " This is the default when no SQL SECURITY characteristic is specified; use SQL SECURITY INVOKER instead."
+		}
+		return message
+	})
+}
+
+func nonDeterministicFuncDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, _ Options) []*Annotation {
+	return routineAnnotations(schema, logicalSchema, "Function not deterministic", nil, func(routine *tengo.Routine) string {
+		if routine.Type != tengo.ObjectTypeFunc || routine.Deterministic || routine.SQLDataAccess == "NO SQL" || routine.SQLDataAccess == "READS SQL DATA" {
+			return ""
+		}
+		return fmt.Sprintf("Function %s is not declared as DETERMINISTIC, NO SQL, or READS SQL DATA. Creating it will fail when binary logging is enabled, unless log_bin_trust_function_creators is enabled; and calling it is unsafe for statement-based replication.", routine.Name)
+	})
+}
+
+func badSQLModeDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	return routineAnnotations(schema, logicalSchema, "Routine sql_mode mismatch", nil, func(routine *tengo.Routine) string {
+		actual := make(map[string]bool)
+		for _, mode := range strings.Split(routine.SQLMode, ",") {
+			if mode != "" {
+				actual[strings.ToUpper(mode)] = true
+			}
+		}
+		required := make(map[string]bool, len(opts.RequiredSQLMode))
+		for _, mode := range opts.RequiredSQLMode {
+			required[strings.ToUpper(mode)] = true
+		}
+		var missing, extra []string
+		for mode := range required {
+			if !actual[mode] {
+				missing = append(missing, mode)
+			}
+		}
+		for mode := range actual {
+			if !required[mode] {
+				extra = append(extra, mode)
+			}
+		}
+		if len(missing) == 0 && len(extra) == 0 {
+			return ""
+		}
+		sort.Strings(missing)
+		sort.Strings(extra)
+		message := fmt.Sprintf("%s %s was created with sql_mode '%s', which differs from option required-sql-mode.", strings.Title(string(routine.Type)), routine.Name, routine.SQLMode)
+		if len(missing) > 0 {
+			message += fmt.Sprintf(" Missing modes: %s.", strings.Join(missing, ", "))
+		}
+		if len(extra) > 0 {
+			message += fmt.Sprintf(" Unexpected modes: %s.", strings.Join(extra, ", "))
+		}
+		return message
+	})
+}
+
+func missingCommentDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	check := func(subject, comment string, stmt *fs.Statement, lineOffset int) {
+		var message string
+		if comment = strings.TrimSpace(comment); comment == "" {
+			message = fmt.Sprintf("%s does not have a comment.", subject)
+		} else if length := utf8.RuneCountInString(comment); length < opts.CommentMinLength {
+			message = fmt.Sprintf("%s has a comment of %d characters, which is shorter than the minimum of %d characters set by option require-comment-min-length.", subject, length, opts.CommentMinLength)
+		} else {
+			return
+		}
+		results = append(results, &Annotation{
+			Statement:  stmt,
+			LineOffset: lineOffset,
+			Summary:    "Missing comment",
+			Message:    message + " Comments are required for objects listed in option require-comment.",
+		})
+	}
+	exempt := func(table *tengo.Table, col *tengo.Column) bool {
+		if isAllowed("primary-key", opts.CommentExempt) && table.PrimaryKey != nil {
+			for _, pkCol := range table.PrimaryKey.Columns {
+				if pkCol == col {
+					return true
+				}
+			}
+		}
+		baseType := columnBaseType(col)
+		return isAllowed("timestamp", opts.CommentExempt) && (baseType == "timestamp" || baseType == "datetime")
+	}
+
+	reTableComment := regexp.MustCompile(`(?i)\bcomment\s*=`)
+	for _, table := range schema.Tables {
+		stmt := logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}]
+		if isAllowed("table", opts.RequiredComments) {
+			check(fmt.Sprintf("Table %s", table.Name), table.Comment, stmt, findLastLineOffset(reTableComment, stmt.Text))
+		}
+		if isAllowed("column", opts.RequiredComments) {
+			for _, col := range table.Columns {
+				if !exempt(table, col) {
+					check(fmt.Sprintf("Column %s of table %s", col.Name, table.Name), col.Comment, stmt, columnLineOffset(col, stmt))
+				}
+			}
+		}
+		if isAllowed("index", opts.RequiredComments) {
+			for _, idx := range table.SecondaryIndexes {
+				check(fmt.Sprintf("Index %s of table %s", idx.Name, table.Name), idx.Comment, stmt, findFirstLineOffset(indexDefinitionRegexp(idx), stmt.Text))
+			}
+		}
+	}
+	return results
+}
+
+func tooManyColumnsDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		if len(table.Columns) <= opts.MaxColumns {
+			continue
+		}
+		stmt := logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}]
+		results = append(results, &Annotation{
+			Statement:  stmt,
+			LineOffset: columnLineOffset(table.Columns[opts.MaxColumns], stmt),
+			Summary:    "Too many columns",
+			Message:    fmt.Sprintf("Table %s has %d columns, which exceeds the limit of %d set by option max-columns.", table.Name, len(table.Columns), opts.MaxColumns),
+		})
+	}
+	return results
+}
+
+func tooManyIndexesDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		indexes := tableIndexes(table)
+		if len(indexes) <= opts.MaxIndexes {
+			continue
+		}
+		stmt := logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}]
+		results = append(results, &Annotation{
+			Statement:  stmt,
+			LineOffset: indexLineOffset(indexes[opts.MaxIndexes], stmt),
+			Summary:    "Too many indexes",
+			Message:    fmt.Sprintf("Table %s has %d indexes, which exceeds the limit of %d set by option max-indexes. Each additional index slows down writes and consumes space.", table.Name, len(indexes), opts.MaxIndexes),
+		})
+	}
+	return results
+}
+
+func indexTooLongDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		stmt := logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}]
+		rowFormat := innoDBRowFormat(table, opts.Flavor)
+		engineLimit := maxKeyLengths[table.Engine]
+		for _, idx := range tableIndexes(table) {
+			subject := fmt.Sprintf("Index %s of table %s", idx.Name, table.Name)
+			if idx.PrimaryKey {
+				subject = fmt.Sprintf("The primary key of table %s", table.Name)
+			}
+			var message string
+			if opts.MaxIndexKeyLength > 0 {
+				if keyLength, ok := indexKeyLength(idx); ok && keyLength > opts.MaxIndexKeyLength {
+					message = fmt.Sprintf("%s has a maximum key length of %d bytes, which exceeds the limit of %d bytes set by option max-index-key-length.", subject, keyLength, opts.MaxIndexKeyLength)
+				}
+			} else if keyLength, ok := indexKeyLength(idx); ok && engineLimit > 0 && keyLength > engineLimit {
+				message = fmt.Sprintf("%s has a maximum key length of %d bytes, which exceeds the %s limit of %d bytes.", subject, keyLength, table.Engine, engineLimit)
+			} else if rowFormat == "COMPACT" || rowFormat == "REDUNDANT" {
+				for n, col := range idx.Columns {
+					if colLength, ok := indexColumnKeyLength(idx, n); ok && colLength > maxInnoDBCompactPrefixLength {
+						message = fmt.Sprintf("%s has a maximum key length of %d bytes for column %s, which exceeds the limit of %d bytes per column for InnoDB tables using ROW_FORMAT=%s.", subject, colLength, col.Name, maxInnoDBCompactPrefixLength, rowFormat)
+						break
+					}
+				}
+			}
+			if message == "" {
+				continue
+			}
+			results = append(results, &Annotation{
+				Statement:  stmt,
+				LineOffset: indexLineOffset(idx, stmt),
+				Summary:    "Index key too long",
+				Message:    message + " Consider using a prefix index or a column with a smaller character set.",
 			})
 		}
 	}
+	return results
+}
+
+func rowTooLargeDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, table := range schema.Tables {
+		if rowSize := rowSizeEstimate(table); rowSize > opts.MaxRowSize {
+			results = append(results, &Annotation{
+				Statement: logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}],
+				Summary:   "Row size too large",
+				Message:   fmt.Sprintf("Table %s has an estimated maximum row size of %d bytes, which exceeds the limit of %d bytes set by option max-row-size. BLOB, TEXT, and JSON columns only count towards this estimate by the size of their in-row pointer.", table.Name, rowSize, opts.MaxRowSize),
+			})
+		}
+	}
+	return results
+}
+
+func varcharTooLongDetector(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, opts Options) []*Annotation {
+	return columnTypeAnnotations(schema, logicalSchema, "VARCHAR too long", func(table *tengo.Table, col *tengo.Column) string {
+		if columnBaseType(col) != "varchar" {
+			return ""
+		}
+		maxBytes, _ := util.ColumnMaxBytes(col)
+		length := maxBytes / util.CharSetMaxBytes(col.CharSet)
+		if length <= uint64(opts.MaxVarcharLength) {
+			return ""
+		}
+		return fmt.Sprintf("Column %s of table %s is using type %s, which exceeds the maximum VARCHAR length of %d set by option max-varchar-length. Consider using a TEXT type instead.", col.Name, table.Name, col.TypeInDB, opts.MaxVarcharLength)
+	})
+}
+
+// tableIndexes returns all indexes of table, with the primary key (if any)
+// first, followed by secondary indexes in order.
+func tableIndexes(table *tengo.Table) []*tengo.Index {
+	indexes := make([]*tengo.Index, 0, len(table.SecondaryIndexes)+1)
+	if table.PrimaryKey != nil {
+		indexes = append(indexes, table.PrimaryKey)
+	}
+	return append(indexes, table.SecondaryIndexes...)
+}
+
+// indexKeyLength returns the maximum length in bytes of a key in idx. If idx
+// includes a column whose size cannot be determined without a prefix length,
+// ok will be false.
+func indexKeyLength(idx *tengo.Index) (keyLength uint64, ok bool) {
+	for n := range idx.Columns {
+		colLength, colOK := indexColumnKeyLength(idx, n)
+		if !colOK {
+			return 0, false
+		}
+		keyLength += colLength
+	}
+	return keyLength, true
+}
+
+// indexColumnKeyLength returns the maximum length in bytes of the part of idx's
+// key for its column at position n. If the column's type cannot be indexed
+// without a prefix length, ok will be false.
+func indexColumnKeyLength(idx *tengo.Index, n int) (colLength uint64, ok bool) {
+	col := idx.Columns[n]
+	if n < len(idx.SubParts) && idx.SubParts[n] > 0 {
+		return uint64(idx.SubParts[n]) * util.CharSetMaxBytes(col.CharSet), true
+	}
+	return util.ColumnMaxBytes(col)
+}
+
+// maxKeyLengths maps storage engines to their limit on index key length, in
+// bytes. Without max-index-key-length, indexes of tables using other engines
+// are not checked.
+var maxKeyLengths = map[string]uint64{
+	"InnoDB": 3072,
+	"MyISAM": 1000,
+}
+
+// maxInnoDBCompactPrefixLength is the limit on each column's portion of an
+// index key, in bytes, for InnoDB tables using the COMPACT or REDUNDANT row
+// format.
+const maxInnoDBCompactPrefixLength = 767
+
+var reRowFormat = regexp.MustCompile(`(?i)ROW_FORMAT=(\w+)`)
+
+// innoDBRowFormat returns the upper-case ROW_FORMAT of table if it uses the
+// InnoDB storage engine, or an empty string otherwise. If table does not
+// specify a ROW_FORMAT explicitly, the server default for flavor is returned.
+func innoDBRowFormat(table *tengo.Table, flavor tengo.Flavor) string {
+	if table.Engine != "InnoDB" {
+		return ""
+	} else if matches := reRowFormat.FindStringSubmatch(table.CreateOptions); matches != nil {
+		return strings.ToUpper(matches[1])
+	} else if flavor.Known() && !flavor.MySQLishMinVersion(5, 7) && !flavor.VendorMinVersion(tengo.VendorMariaDB, 10, 2) {
+		return "COMPACT"
+	}
+	return "DYNAMIC"
+}
 
+// rowSizeEstimate returns the maximum size in bytes of a row of table, as
+// counted towards the server's row size limit. Columns which may be stored
+// off-page are only counted by the size of their in-row pointer.
+func rowSizeEstimate(table *tengo.Table) uint64 {
+	var rowSize, nullable uint64
+	for _, col := range table.Columns {
+		colBytes, ok := util.ColumnMaxBytes(col)
+		if !ok {
+			colBytes = 12
+		} else if baseType := columnBaseType(col); baseType == "varchar" || baseType == "varbinary" {
+			// Length prefix
+			if colBytes > 255 {
+				colBytes += 2
+			} else {
+				colBytes++
+			}
+		}
+		rowSize += colBytes
+		if col.Nullable {
+			nullable++
+		}
+	}
+	return rowSize + (nullable+7)/8
+}
+
+// indexLineOffset returns the line offset of idx's definition within stmt. If
+// the definition cannot be found, 0 is returned.
+func indexLineOffset(idx *tengo.Index, stmt *fs.Statement) int {
+	if idx.PrimaryKey {
+		return findFirstLineOffset(regexp.MustCompile(`(?i)primary\s+key`), stmt.Text)
+	}
+	return findFirstLineOffset(indexDefinitionRegexp(idx), stmt.Text)
+}
+
+// routineAnnotations returns an annotation for each routine in schema for
+// which makeMessage returns a non-empty string. If re is non-nil, each
+// annotation points to the first line of the routine's CREATE matching re.
+func routineAnnotations(schema *tengo.Schema, logicalSchema *fs.LogicalSchema, summary string, re *regexp.Regexp, makeMessage func(*tengo.Routine) string) []*Annotation {
+	results := make([]*Annotation, 0)
+	for _, routine := range schema.Routines {
+		message := makeMessage(routine)
+		if message == "" {
+			continue
+		}
+		stmt := logicalSchema.Creates[tengo.ObjectKey{Type: routine.Type, Name: routine.Name}]
+		var lineOffset int
+		if re != nil {
+			lineOffset = findFirstLineOffset(re, stmt.Text)
+		}
+		results = append(results, &Annotation{
+			Statement:  stmt,
+			LineOffset: lineOffset,
+			Summary:    summary,
+			Message:    message,
+		})
+	}
 	return results
 }
 
+// definerMatches returns true if definer, in the form user@host, matches the
+// supplied pattern. The pattern may use % as a wildcard. Comparison is
+// case-insensitive, and any quote characters are ignored.
+func definerMatches(definer, pattern string) bool {
+	stripQuotes := strings.NewReplacer("`", "", "'", "", `"`, "")
+	definer, pattern = stripQuotes.Replace(definer), stripQuotes.Replace(pattern)
+	parts := strings.Split(pattern, "%")
+	for n := range parts {
+		parts[n] = regexp.QuoteMeta(parts[n])
+	}
+	re := regexp.MustCompile("(?i)^" + strings.Join(parts, ".*") + "$")
+	return re.MatchString(definer)
+}
+
+// sameSchema returns true if a foreign key's ReferencedSchemaName refers to
+// the schema being linted. This may be the workspace schema's name, or the
+// logical schema's name if one was specified explicitly in the *.sql files.
+func sameSchema(referencedSchemaName string, schema *tengo.Schema, logicalSchema *fs.LogicalSchema) bool {
+	return referencedSchemaName == "" || referencedSchemaName == schema.Name || (logicalSchema.Name != "" && referencedSchemaName == logicalSchema.Name)
+}
+
+// foreignKeyLineOffset returns the line offset of fk's CONSTRAINT clause within
+// stmt. If the constraint was not explicitly named, 0 is returned.
+func foreignKeyLineOffset(fk *tengo.ForeignKey, stmt *fs.Statement) int {
+	return findFirstLineOffset(foreignKeyRegexp(fk), stmt.Text)
+}
+
+// foreignKeyRegexp returns a regular expression matching the CONSTRAINT clause
+// of fk within a CREATE TABLE statement.
+func foreignKeyRegexp(fk *tengo.ForeignKey) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf("(?i)constraint\\s+`?%s`?\\s", regexp.QuoteMeta(fk.Name)))
+}
+
+// indexDefinitionRegexp returns a regular expression matching the definition
+// of idx within a CREATE TABLE statement.
+func indexDefinitionRegexp(idx *tengo.Index) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf("(?i)(key|index)\\s+`?%s`?\\s*\\(", regexp.QuoteMeta(idx.Name)))
+}
+
 func problemExists(name string) bool {
 	_, ok := problems[strings.ToLower(name)]
 	return ok
@@ -3,9 +3,11 @@ package linter
 import (
 	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
 )
 
 func TestProblemExists(t *testing.T) {
@@ -18,7 +20,7 @@ func TestProblemExists(t *testing.T) {
 }
 
 func TestAllProblemNames(t *testing.T) {
-	expected := []string{"bad-charset", "bad-engine", "no-pk"}
+	expected := []string{"auto-inc-capacity", "bad-charset", "bad-definer", "bad-engine", "bad-name", "bad-sql-mode", "cross-schema-column-mismatch", "cross-schema-missing-table", "dupe-index", "fk-cross-schema", "fk-missing-index", "fk-type-mismatch", "has-enum", "has-fk", "has-float", "has-timestamp", "index-too-long", "missing-comment", "no-pk", "non-deterministic-func", "pk-type", "plan-charset-conversion", "plan-copy-alter", "plan-drop-fk-index", "plan-not-null-no-default", "plan-pk-change", "row-too-large", "security-definer", "too-many-columns", "too-many-indexes", "upgrade-canonical-change", "upgrade-collation-change", "upgrade-removed-feature", "upgrade-reserved-word", "upgrade-sql-error", "varchar-too-long"}
 	actual := allProblemNames()
 	if !reflect.DeepEqual(actual, expected) {
 		t.Errorf("allProblemNames returned %+v, did not match expectation %+v", actual, expected)
@@ -30,7 +32,7 @@ func TestAllProblemNames(t *testing.T) {
 		// Clean up the global state
 		delete(problems, "new-prob")
 	}()
-	expected = []string{"bad-charset", "bad-engine", "new-prob", "no-pk"}
+	expected = []string{"auto-inc-capacity", "bad-charset", "bad-definer", "bad-engine", "bad-name", "bad-sql-mode", "cross-schema-column-mismatch", "cross-schema-missing-table", "dupe-index", "fk-cross-schema", "fk-missing-index", "fk-type-mismatch", "has-enum", "has-fk", "has-float", "has-timestamp", "index-too-long", "missing-comment", "new-prob", "no-pk", "non-deterministic-func", "pk-type", "plan-charset-conversion", "plan-copy-alter", "plan-drop-fk-index", "plan-not-null-no-default", "plan-pk-change", "row-too-large", "security-definer", "too-many-columns", "too-many-indexes", "upgrade-canonical-change", "upgrade-collation-change", "upgrade-removed-feature", "upgrade-reserved-word", "upgrade-sql-error", "varchar-too-long"}
 	actual = allProblemNames()
 	if !reflect.DeepEqual(actual, expected) {
 		t.Errorf("allProblemNames returned %+v, did not match expectation %+v", actual, expected)
@@ -69,3 +71,542 @@ func TestFindLastLineOffset(t *testing.T) {
 		t.Errorf("Expected last line offset to be 0, instead found %d", actual)
 	}
 }
+
+func TestDupeIndexDetector(t *testing.T) {
+	cols := []*tengo.Column{{Name: "id"}, {Name: "a"}, {Name: "b"}, {Name: "c"}}
+	makeIndex := func(name string, unique bool, colNums ...int) *tengo.Index {
+		idx := &tengo.Index{Name: name, Unique: unique, SubParts: make([]uint16, len(colNums))}
+		for _, n := range colNums {
+			idx.Columns = append(idx.Columns, cols[n])
+		}
+		return idx
+	}
+	pk := makeIndex("PRIMARY", true, 0, 1)
+	pk.PrimaryKey = true
+	table := &tengo.Table{
+		Name:       "t",
+		Columns:    cols,
+		PrimaryKey: pk,
+		SecondaryIndexes: []*tengo.Index{
+			makeIndex("pkprefix", false, 0),       // redundant to PK
+			makeIndex("ab", false, 1, 2),          // redundant to abc
+			makeIndex("abc", false, 1, 2, 3),      // not redundant
+			makeIndex("abc_dupe", false, 1, 2, 3), // duplicate of abc
+			makeIndex("uniq_a", true, 1),          // unique, and no other unique index has just this column
+			makeIndex("uniq_ab", true, 1, 2),      // unique, but ok since uniq_a has fewer cols
+			makeIndex("uniq_pk", true, 0, 1),      // duplicate of PK
+		},
+		ForeignKeys: []*tengo.ForeignKey{
+			{Name: "fk_a", Columns: []*tengo.Column{cols[1]}},
+		},
+	}
+	schema := &tengo.Schema{Name: "s", Tables: []*tengo.Table{table}}
+	stmt := &fs.Statement{
+		File: "t.sql",
+		Text: "CREATE TABLE t (\n  id int,\n  a int,\n  b int,\n  c int,\n  PRIMARY KEY (id, a),\n  KEY pkprefix (id),\n  KEY `ab` (a, b),\n  KEY abc (a, b, c),\n  KEY abc_dupe (a,b,c),\n  UNIQUE KEY uniq_a (a),\n  UNIQUE KEY uniq_ab (a, b),\n  UNIQUE KEY uniq_pk (id, a)\n)",
+	}
+	logicalSchema := &fs.LogicalSchema{
+		Name:    "s",
+		Creates: map[tengo.ObjectKey]*fs.Statement{{Type: tengo.ObjectTypeTable, Name: "t"}: stmt},
+	}
+
+	expected := map[string]int{ // index name -> line offset
+		"pkprefix": 6,
+		"ab":       7,
+		"abc_dupe": 9,
+		"uniq_pk":  12,
+	}
+	annotations := dupeIndexDetector(schema, logicalSchema, Options{})
+	if len(annotations) != len(expected) {
+		t.Fatalf("Expected %d annotations, instead found %d", len(expected), len(annotations))
+	}
+	for _, a := range annotations {
+		var found bool
+		for name, lineOffset := range expected {
+			if strings.HasPrefix(a.Message, "Index "+name+" ") {
+				found = true
+				if a.LineOffset != lineOffset {
+					t.Errorf("Expected annotation for %s to have line offset %d, instead found %d", name, lineOffset, a.LineOffset)
+				}
+				if name == "ab" && !strings.Contains(a.Message, "fk_a") {
+					t.Errorf("Expected annotation for %s to mention foreign key, but it did not: %s", name, a.Message)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Unexpected annotation: %s", a.Message)
+		}
+	}
+}
+
+func TestForeignKeyDetectors(t *testing.T) {
+	parentID := &tengo.Column{Name: "id", TypeInDB: "int(10) unsigned"}
+	parentCode := &tengo.Column{Name: "code", TypeInDB: "varchar(10)", CharSet: "utf8mb4", Collation: "utf8mb4_general_ci"}
+	parent := &tengo.Table{
+		Name:       "parent",
+		Columns:    []*tengo.Column{parentID, parentCode},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{parentID}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+	}
+	childID := &tengo.Column{Name: "id", TypeInDB: "int(10) unsigned"}
+	childParentID := &tengo.Column{Name: "parent_id", TypeInDB: "int(11)"}
+	childCode := &tengo.Column{Name: "parent_code", TypeInDB: "varchar(10)", CharSet: "latin1", Collation: "latin1_swedish_ci"}
+	childOtherID := &tengo.Column{Name: "other_id", TypeInDB: "int(10) unsigned"}
+	child := &tengo.Table{
+		Name:       "child",
+		Columns:    []*tengo.Column{childID, childParentID, childCode, childOtherID},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{childID}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		SecondaryIndexes: []*tengo.Index{
+			{Name: "parent_id", Columns: []*tengo.Column{childParentID, childOtherID}, SubParts: []uint16{0, 0}},
+			{Name: "fk_code", Columns: []*tengo.Column{childCode}, SubParts: []uint16{0}},
+			{Name: "fk_other", Columns: []*tengo.Column{childOtherID}, SubParts: []uint16{0}},
+		},
+		ForeignKeys: []*tengo.ForeignKey{
+			{Name: "fk_code", Columns: []*tengo.Column{childCode}, ReferencedTableName: "parent", ReferencedColumnNames: []string{"code"}},
+			{Name: "fk_other", Columns: []*tengo.Column{childOtherID}, ReferencedSchemaName: "otherdb", ReferencedTableName: "other", ReferencedColumnNames: []string{"id"}},
+			{Name: "fk_parent", Columns: []*tengo.Column{childParentID}, ReferencedTableName: "parent", ReferencedColumnNames: []string{"id"}},
+		},
+	}
+	schema := &tengo.Schema{Name: "_skeema_tmp", Tables: []*tengo.Table{child, parent}}
+	childStmt := &fs.Statement{
+		File: "child.sql",
+		Text: "CREATE TABLE child (\n  id int unsigned,\n  parent_id int,\n  parent_code varchar(10) CHARSET latin1,\n  other_id int unsigned,\n  PRIMARY KEY (id),\n  KEY parent_id (parent_id, other_id),\n  KEY fk_other (other_id),\n  CONSTRAINT fk_code FOREIGN KEY (parent_code) REFERENCES parent (code),\n  CONSTRAINT fk_other FOREIGN KEY (other_id) REFERENCES otherdb.other (id),\n  CONSTRAINT `fk_parent` FOREIGN KEY (parent_id) REFERENCES parent (id)\n)",
+	}
+	logicalSchema := &fs.LogicalSchema{
+		Creates: map[tengo.ObjectKey]*fs.Statement{
+			{Type: tengo.ObjectTypeTable, Name: "child"}:  childStmt,
+			{Type: tengo.ObjectTypeTable, Name: "parent"}: {File: "parent.sql", Text: "CREATE TABLE parent (...)"},
+		},
+	}
+
+	// Map of problem name -> expected line offsets, in order of foreign key
+	expected := map[string][]int{
+		"has-fk":           {8, 9, 10},
+		"fk-missing-index": {8}, // fk_code's index was created implicitly
+		"fk-type-mismatch": {8, 10},
+		"fk-cross-schema":  {9},
+	}
+	for problem, offsets := range expected {
+		annotations := problems[problem](schema, logicalSchema, Options{})
+		if len(annotations) != len(offsets) {
+			t.Errorf("Expected %s to return %d annotations, instead found %d", problem, len(offsets), len(annotations))
+			continue
+		}
+		for n, a := range annotations {
+			if a.LineOffset != offsets[n] || a.Statement != childStmt {
+				t.Errorf("Unexpected annotation %d for %s: %+v", n, problem, a)
+			}
+		}
+	}
+
+	// Integer display width differences are not considered a type mismatch
+	childParentID.TypeInDB = "int(11) unsigned"
+	if annotations := fkTypeMismatchDetector(schema, logicalSchema, Options{}); len(annotations) != 1 || annotations[0].LineOffset != 8 {
+		t.Errorf("Expected only fk_code to be flagged as a type mismatch, instead found %+v", annotations)
+	}
+
+	// If the schema name is referenced explicitly, it is not considered cross-schema
+	logicalSchema.Name = "otherdb"
+	if annotations := fkCrossSchemaDetector(schema, logicalSchema, Options{}); len(annotations) != 0 {
+		t.Errorf("Expected no annotations, instead found %d", len(annotations))
+	}
+}
+
+func TestColumnTypeDetectors(t *testing.T) {
+	cols := []*tengo.Column{
+		{Name: "id", TypeInDB: "int(10) unsigned", AutoIncrement: true},
+		{Name: "price", TypeInDB: "double"},
+		{Name: "weight", TypeInDB: "float(7,4)"},
+		{Name: "status", TypeInDB: "enum('a','b')"},
+		{Name: "flags", TypeInDB: "set('x','y')"},
+		{Name: "created_at", TypeInDB: "timestamp(6)"},
+		{Name: "updated_at", TypeInDB: "datetime"},
+	}
+	table := &tengo.Table{
+		Name:       "t",
+		Columns:    cols,
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: cols[0:1], SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+	}
+	schema := &tengo.Schema{Name: "s", Tables: []*tengo.Table{table}}
+	stmt := &fs.Statement{
+		File: "t.sql",
+		Text: "CREATE TABLE t (\n  id int unsigned auto_increment,\n  `price` double,\n  weight float(7,4),\n  status enum('a','b'),\n  flags set('x','y'),\n  created_at timestamp(6),\n  updated_at datetime,\n  PRIMARY KEY (id)\n)",
+	}
+	logicalSchema := &fs.LogicalSchema{
+		Creates: map[tengo.ObjectKey]*fs.Statement{{Type: tengo.ObjectTypeTable, Name: "t"}: stmt},
+	}
+	opts := Options{AllowedPKTypes: []string{"bigint"}}
+
+	expected := map[string][]int{ // problem name -> line offsets
+		"has-float":     {2, 3},
+		"has-enum":      {4, 5},
+		"has-timestamp": {6},
+		"pk-type":       {1},
+	}
+	for problem, offsets := range expected {
+		annotations := problems[problem](schema, logicalSchema, opts)
+		if len(annotations) != len(offsets) {
+			t.Errorf("Expected %s to return %d annotations, instead found %d", problem, len(offsets), len(annotations))
+			continue
+		}
+		for n, a := range annotations {
+			if a.LineOffset != offsets[n] {
+				t.Errorf("Expected annotation %d for %s to have line offset %d, instead found %d", n, problem, offsets[n], a.LineOffset)
+			}
+		}
+	}
+
+	// allow-float, allow-enum, and allow-timestamp exempt columns by name
+	opts.AllowFloat = regexp.MustCompile(`^weight$`)
+	opts.AllowEnum = regexp.MustCompile(`^(status|flags)$`)
+	opts.AllowTimestamp = regexp.MustCompile(`_at$`)
+	expected = map[string][]int{
+		"has-float":     {2},
+		"has-enum":      {},
+		"has-timestamp": {},
+	}
+	for problem, offsets := range expected {
+		annotations := problems[problem](schema, logicalSchema, opts)
+		if len(annotations) != len(offsets) {
+			t.Errorf("Expected %s to return %d annotations with allow-list, instead found %d", problem, len(offsets), len(annotations))
+			continue
+		}
+		for n, a := range annotations {
+			if a.LineOffset != offsets[n] {
+				t.Errorf("Expected annotation %d for %s to have line offset %d, instead found %d", n, problem, offsets[n], a.LineOffset)
+			}
+		}
+	}
+
+	// pk-type permits values with or without unsigned
+	for _, allowed := range []string{"int", "INT UNSIGNED", "bigint,int unsigned"} {
+		opts.AllowedPKTypes = strings.Split(allowed, ",")
+		if annotations := pkTypeDetector(schema, logicalSchema, opts); len(annotations) != 0 {
+			t.Errorf("Expected no annotations with allow-pk-type=%s, instead found %d", allowed, len(annotations))
+		}
+	}
+	opts.AllowedPKTypes = []string{"int signed", "bigint unsigned"}
+	if annotations := pkTypeDetector(schema, logicalSchema, opts); len(annotations) != 1 {
+		t.Errorf("Expected 1 annotation with allow-pk-type=%v, instead found %d", opts.AllowedPKTypes, len(annotations))
+	}
+
+	// auto-inc-capacity flags signed int, but not unsigned int
+	if annotations := autoIncCapacityDetector(schema, logicalSchema, opts); len(annotations) != 0 {
+		t.Errorf("Expected no annotations for type %s, instead found %d", cols[0].TypeInDB, len(annotations))
+	}
+	cols[0].TypeInDB = "int(11)"
+	if annotations := autoIncCapacityDetector(schema, logicalSchema, opts); len(annotations) != 1 || annotations[0].LineOffset != 1 {
+		t.Errorf("Unexpected result for type %s: %+v", cols[0].TypeInDB, annotations)
+	}
+}
+
+func TestBadNameDetector(t *testing.T) {
+	id := &tengo.Column{Name: "id", TypeInDB: "bigint(20)"}
+	userID := &tengo.Column{Name: "userId", TypeInDB: "bigint(20)"}
+	rank := &tengo.Column{Name: "rank", TypeInDB: "int(11)"}
+	table := &tengo.Table{
+		Name:       "Users",
+		Columns:    []*tengo.Column{id, userID, rank},
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: []*tengo.Column{id}, SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		SecondaryIndexes: []*tengo.Index{
+			{Name: "rank", Columns: []*tengo.Column{rank}, SubParts: []uint16{0}},      // implicit name, not checked
+			{Name: "by_user", Columns: []*tengo.Column{userID}, SubParts: []uint16{0}}, // does not match pattern
+		},
+		ForeignKeys: []*tengo.ForeignKey{
+			{Name: "Users_ibfk_1", Columns: []*tengo.Column{userID}}, // implicit name, not checked
+		},
+	}
+	schema := &tengo.Schema{
+		Name:     "s",
+		Tables:   []*tengo.Table{table},
+		Routines: []*tengo.Routine{{Name: "doStuff", Type: tengo.ObjectTypeProc}},
+	}
+	stmt := &fs.Statement{
+		File: "users.sql",
+		Text: "CREATE TABLE Users (\n  id bigint,\n  userId bigint,\n  `rank` int,\n  PRIMARY KEY (id),\n  KEY (`rank`),\n  KEY by_user (userId),\n  FOREIGN KEY (userId) REFERENCES Users (id)\n)",
+	}
+	logicalSchema := &fs.LogicalSchema{
+		Creates: map[tengo.ObjectKey]*fs.Statement{
+			{Type: tengo.ObjectTypeTable, Name: "Users"}:  stmt,
+			{Type: tengo.ObjectTypeProc, Name: "doStuff"}: {File: "dostuff.sql", Text: "CREATE PROCEDURE doStuff() SELECT 1"},
+		},
+	}
+	opts := Options{
+		NamePatterns:   map[string]*regexp.Regexp{"index": regexp.MustCompile(`^idx_`)},
+		NamingDisallow: []string{"reserved-word", "mixed-case"},
+		Flavor:         tengo.FlavorMySQL57,
+	}
+
+	// rank is only reserved in MySQL 8
+	expected := map[string]int{ // message prefix -> line offset
+		"The name of table Users ":       0,
+		"The name of column userId ":     2,
+		"The name of index by_user ":     6,
+		"The name of procedure doStuff ": 0,
+	}
+	check := func() {
+		t.Helper()
+		annotations := badNameDetector(schema, logicalSchema, opts)
+		if len(annotations) != len(expected) {
+			t.Errorf("Expected %d annotations, instead found %d", len(expected), len(annotations))
+		}
+		for _, a := range annotations {
+			var found bool
+			for prefix, lineOffset := range expected {
+				if strings.HasPrefix(a.Message, prefix) {
+					found = true
+					if a.LineOffset != lineOffset {
+						t.Errorf("Expected annotation %q to have line offset %d, instead found %d", a.Message, lineOffset, a.LineOffset)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("Unexpected annotation: %s", a.Message)
+			}
+		}
+	}
+	check()
+	opts.Flavor = tengo.FlavorMySQL80
+	expected["The name of column rank "] = 3
+	check()
+
+	// Without reserved-word, rank is permitted; without mixed-case, only the
+	// naming-index pattern is checked
+	opts.NamingDisallow = []string{"mixed-case"}
+	delete(expected, "The name of column rank ")
+	check()
+	opts.NamingDisallow = []string{}
+	expected = map[string]int{"The name of index by_user ": 6}
+	check()
+}
+
+func TestIsReservedWord(t *testing.T) {
+	cases := []struct {
+		word     string
+		flavor   tengo.Flavor
+		expected bool
+	}{
+		{"select", tengo.FlavorMySQL55, true},
+		{"Rank", tengo.FlavorMySQL57, false},
+		{"Rank", tengo.FlavorMySQL80, true},
+		{"rank", tengo.FlavorUnknown, true},
+		{"rank", tengo.FlavorMariaDB103, false},
+		{"intersect", tengo.FlavorMariaDB103, true},
+		{"intersect", tengo.FlavorMySQL80, false},
+		{"generated", tengo.FlavorPercona57, true},
+		{"users", tengo.FlavorUnknown, false},
+	}
+	for _, c := range cases {
+		if actual := isReservedWord(c.word, c.flavor); actual != c.expected {
+			t.Errorf("Expected isReservedWord(%q, %s) to return %t, instead found %t", c.word, c.flavor, c.expected, actual)
+		}
+	}
+}
+
+func TestRoutineDetectors(t *testing.T) {
+	routines := []*tengo.Routine{
+		{Name: "p1", Type: tengo.ObjectTypeProc, Definer: "root@localhost", SecurityType: "DEFINER", SQLMode: "STRICT_TRANS_TABLES,NO_ENGINE_SUBSTITUTION"},
+		{Name: "p2", Type: tengo.ObjectTypeProc, Definer: "app@%", SecurityType: "INVOKER", SQLMode: "NO_ENGINE_SUBSTITUTION,STRICT_TRANS_TABLES"},
+		{Name: "f1", Type: tengo.ObjectTypeFunc, Definer: "app@10.0.0.1", SecurityType: "DEFINER", SQLDataAccess: "CONTAINS SQL", SQLMode: ""},
+		{Name: "f2", Type: tengo.ObjectTypeFunc, Definer: "app@%", SecurityType: "INVOKER", SQLDataAccess: "READS SQL DATA", SQLMode: "STRICT_TRANS_TABLES,NO_ENGINE_SUBSTITUTION"},
+		{Name: "f3", Type: tengo.ObjectTypeFunc, Definer: "app@%", SecurityType: "INVOKER", Deterministic: true, SQLDataAccess: "MODIFIES SQL DATA", SQLMode: "ANSI_QUOTES,STRICT_TRANS_TABLES,NO_ENGINE_SUBSTITUTION"},
+	}
+	schema := &tengo.Schema{Name: "s", Routines: routines}
+	logicalSchema := &fs.LogicalSchema{
+		Creates: map[tengo.ObjectKey]*fs.Statement{
+			{Type: tengo.ObjectTypeProc, Name: "p1"}: {Text: "CREATE\n  DEFINER=`root`@`localhost`\n  PROCEDURE p1()\n  SQL SECURITY DEFINER\n  SELECT 1"},
+			{Type: tengo.ObjectTypeProc, Name: "p2"}: {Text: "CREATE PROCEDURE p2() SQL SECURITY INVOKER SELECT 1"},
+			{Type: tengo.ObjectTypeFunc, Name: "f1"}: {Text: "CREATE FUNCTION f1() RETURNS int\n  RETURN 1"},
+			{Type: tengo.ObjectTypeFunc, Name: "f2"}: {Text: "CREATE FUNCTION f2() RETURNS int READS SQL DATA SQL SECURITY INVOKER RETURN 1"},
+			{Type: tengo.ObjectTypeFunc, Name: "f3"}: {Text: "CREATE FUNCTION f3() RETURNS int DETERMINISTIC MODIFIES SQL DATA SQL SECURITY INVOKER RETURN 1"},
+		},
+	}
+	opts := Options{
+		AllowedDefiners: []string{"'app'@'%'"},
+		RequiredSQLMode: []string{"strict_trans_tables", "no_engine_substitution"},
+	}
+
+	expected := map[string]map[string]int{ // problem -> routine name -> line offset
+		"bad-definer":            {"p1": 1},
+		"security-definer":       {"p1": 3, "f1": 0},
+		"non-deterministic-func": {"f1": 0},
+		"bad-sql-mode":           {"f1": 0, "f3": 0},
+	}
+	for problem, expectRoutines := range expected {
+		annotations := problems[problem](schema, logicalSchema, opts)
+		if len(annotations) != len(expectRoutines) {
+			t.Errorf("Expected %s to return %d annotations, instead found %d", problem, len(expectRoutines), len(annotations))
+			continue
+		}
+		for _, a := range annotations {
+			var found bool
+			for name, lineOffset := range expectRoutines {
+				if a.Statement == logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeProc, Name: name}] || a.Statement == logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeFunc, Name: name}] {
+					found = true
+					if a.LineOffset != lineOffset {
+						t.Errorf("Expected %s annotation for %s to have line offset %d, instead found %d", problem, name, lineOffset, a.LineOffset)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("Unexpected %s annotation: %s", problem, a.Message)
+			}
+		}
+	}
+}
+
+func TestMissingCommentDetector(t *testing.T) {
+	cols := []*tengo.Column{
+		{Name: "id", TypeInDB: "bigint(20) unsigned"},
+		{Name: "name", TypeInDB: "varchar(30)", Comment: "Display name"},
+		{Name: "code", TypeInDB: "char(2)", Comment: "cc"},
+		{Name: "created_at", TypeInDB: "timestamp"},
+	}
+	table := &tengo.Table{
+		Name:             "t",
+		Columns:          cols,
+		PrimaryKey:       &tengo.Index{Name: "PRIMARY", Columns: cols[0:1], PrimaryKey: true, Unique: true},
+		SecondaryIndexes: []*tengo.Index{{Name: "idx_name", Columns: cols[1:2]}},
+	}
+	schema := &tengo.Schema{Tables: []*tengo.Table{table}}
+	logicalSchema := &fs.LogicalSchema{
+		Creates: map[tengo.ObjectKey]*fs.Statement{
+			{Type: tengo.ObjectTypeTable, Name: "t"}: {Text: "CREATE TABLE t (\n  id bigint unsigned,\n  name varchar(30) COMMENT 'Display name',\n  code char(2) COMMENT 'cc',\n  created_at timestamp,\n  PRIMARY KEY (id),\n  KEY idx_name (name)\n)"},
+		},
+	}
+
+	cases := []struct {
+		opts        Options
+		lineOffsets []int
+	}{
+		{Options{RequiredComments: []string{"table", "column"}, CommentMinLength: 1, CommentExempt: []string{"primary-key", "timestamp"}}, []int{0}},
+		{Options{RequiredComments: []string{"column"}, CommentMinLength: 3, CommentExempt: []string{"primary-key"}}, []int{3, 4}},
+		{Options{RequiredComments: []string{"column", "index"}, CommentMinLength: 1}, []int{1, 4, 6}},
+	}
+	for n, c := range cases {
+		annotations := missingCommentDetector(schema, logicalSchema, c.opts)
+		var lineOffsets []int
+		for _, a := range annotations {
+			lineOffsets = append(lineOffsets, a.LineOffset)
+		}
+		if !reflect.DeepEqual(lineOffsets, c.lineOffsets) {
+			t.Errorf("cases[%d]: Expected annotations at line offsets %v, instead found %v", n, c.lineOffsets, lineOffsets)
+		}
+	}
+}
+
+func TestSizeLimitDetectors(t *testing.T) {
+	cols := []*tengo.Column{
+		{Name: "id", TypeInDB: "bigint(20) unsigned"},
+		{Name: "name", TypeInDB: "varchar(800)", Nullable: true, CharSet: "utf8mb4"},
+		{Name: "code", TypeInDB: "varchar(20)", CharSet: "latin1"},
+		{Name: "body", TypeInDB: "text", Nullable: true, CharSet: "utf8mb4"},
+		{Name: "notes", TypeInDB: "varchar(16000)", Nullable: true, CharSet: "utf8mb4"},
+	}
+	table := &tengo.Table{
+		Name:       "t",
+		Columns:    cols,
+		PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: cols[0:1], SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		SecondaryIndexes: []*tengo.Index{
+			{Name: "idx_name", Columns: cols[1:3], SubParts: []uint16{0, 0}},
+			{Name: "idx_body", Columns: cols[3:4], SubParts: []uint16{191}},
+			{Name: "idx_code", Columns: cols[2:3], SubParts: []uint16{0}},
+		},
+	}
+	schema := &tengo.Schema{Tables: []*tengo.Table{table}}
+	logicalSchema := &fs.LogicalSchema{
+		Creates: map[tengo.ObjectKey]*fs.Statement{
+			{Type: tengo.ObjectTypeTable, Name: "t"}: {Text: "CREATE TABLE t (\n  id bigint unsigned,\n  name varchar(800),\n  code varchar(20),\n  body text,\n  notes varchar(16000),\n  PRIMARY KEY (id),\n  KEY idx_name (name, code),\n  KEY idx_body (body(191)),\n  KEY idx_code (code)\n)"},
+		},
+	}
+	opts := Options{
+		MaxColumns:        4,
+		MaxIndexes:        3,
+		MaxIndexKeyLength: 3072,
+		MaxRowSize:        65535,
+		MaxVarcharLength:  1024,
+	}
+
+	expected := map[string][]int{ // problem -> line offsets
+		"too-many-columns": {5},
+		"too-many-indexes": {9},
+		"index-too-long":   {7},
+		"row-too-large":    {0},
+		"varchar-too-long": {5},
+	}
+	for problem, expectOffsets := range expected {
+		var lineOffsets []int
+		for _, a := range problems[problem](schema, logicalSchema, opts) {
+			lineOffsets = append(lineOffsets, a.LineOffset)
+		}
+		if !reflect.DeepEqual(lineOffsets, expectOffsets) {
+			t.Errorf("Expected %s annotations at line offsets %v, instead found %v", problem, expectOffsets, lineOffsets)
+		}
+	}
+
+	// Without max-index-key-length, the limit depends on the storage engine and
+	// row format
+	opts.MaxIndexKeyLength = 0
+	cases := []struct {
+		engine        string
+		createOptions string
+		flavor        tengo.Flavor
+		bodyPrefix    uint16
+		lineOffsets   []int
+	}{
+		{"InnoDB", "", tengo.FlavorUnknown, 200, []int{7}},
+		{"InnoDB", "", tengo.FlavorMySQL57, 200, []int{7}},
+		{"InnoDB", "", tengo.FlavorMySQL56, 200, []int{7, 8}},
+		{"InnoDB", "ROW_FORMAT=DYNAMIC", tengo.FlavorMySQL56, 200, []int{7}},
+		{"InnoDB", "ROW_FORMAT=COMPACT", tengo.FlavorMySQL80, 200, []int{7, 8}},
+		{"InnoDB", "ROW_FORMAT=REDUNDANT", tengo.FlavorMariaDB103, 200, []int{7, 8}},
+		{"InnoDB", "", tengo.FlavorMySQL57, 300, []int{7}},
+		{"MyISAM", "", tengo.FlavorMySQL57, 200, []int{7}},
+		{"MyISAM", "", tengo.FlavorMySQL57, 300, []int{7, 8}},
+		{"MEMORY", "", tengo.FlavorMySQL57, 300, nil},
+	}
+	for n, c := range cases {
+		table.Engine, table.CreateOptions, opts.Flavor = c.engine, c.createOptions, c.flavor
+		table.SecondaryIndexes[1].SubParts[0] = c.bodyPrefix
+		var lineOffsets []int
+		for _, a := range indexTooLongDetector(schema, logicalSchema, opts) {
+			lineOffsets = append(lineOffsets, a.LineOffset)
+		}
+		if !reflect.DeepEqual(lineOffsets, c.lineOffsets) {
+			t.Errorf("cases[%d]: Expected index-too-long annotations at line offsets %v, instead found %v", n, c.lineOffsets, lineOffsets)
+		}
+	}
+	table.SecondaryIndexes[1].SubParts[0] = 191
+
+	if rowSize := rowSizeEstimate(table); rowSize != 8+3202+21+12+64002+1 {
+		t.Errorf("Unexpected result from rowSizeEstimate: %d", rowSize)
+	}
+	if keyLength, ok := indexKeyLength(table.SecondaryIndexes[1]); !ok || keyLength != 764 {
+		t.Errorf("Unexpected result from indexKeyLength: %d, %t", keyLength, ok)
+	}
+	if _, ok := indexKeyLength(&tengo.Index{Columns: cols[3:4], SubParts: []uint16{0}}); ok {
+		t.Error("Expected indexKeyLength to return ok=false for TEXT column without prefix, but it returned true")
+	}
+}
+
+func TestDefinerMatches(t *testing.T) {
+	cases := []struct {
+		definer  string
+		pattern  string
+		expected bool
+	}{
+		{"root@localhost", "root@localhost", true},
+		{"root@localhost", "`root`@`localhost`", true},
+		{"ROOT@localhost", "root@%", true},
+		{"app@10.0.0.1", "app@10.%", true},
+		{"app@10.0.0.1", "app@10.0.0.2", false},
+		{"app_user@%", "app@%", false},
+		{"app_user@%", "%@%", true},
+		{"app.user@host", "app%user@host", true},
+		{"appxuser@host", "app.user@host", false},
+	}
+	for _, c := range cases {
+		if actual := definerMatches(c.definer, c.pattern); actual != c.expected {
+			t.Errorf("Expected definerMatches(%q, %q) to return %t, instead found %t", c.definer, c.pattern, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package linter
+
+import (
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// reservedWordGroups lists reserved words, grouped by which flavors treat them
+// as reserved. If the flavor is not known, all groups apply.
+var reservedWordGroups = []struct {
+	words   string
+	applies func(tengo.Flavor) bool
+	wordSet map[string]bool // populated from words by init
+}{
+	{ // Reserved in all supported flavors
+		words: `ACCESSIBLE ADD ALL ALTER ANALYZE AND AS ASC ASENSITIVE BEFORE BETWEEN
+			BIGINT BINARY BLOB BOTH BY CALL CASCADE CASE CHANGE CHAR CHARACTER CHECK
+			COLLATE COLUMN CONDITION CONSTRAINT CONTINUE CONVERT CREATE CROSS
+			CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP CURRENT_USER CURSOR DATABASE
+			DATABASES DAY_HOUR DAY_MICROSECOND DAY_MINUTE DAY_SECOND DEC DECIMAL
+			DECLARE DEFAULT DELAYED DELETE DESC DESCRIBE DETERMINISTIC DISTINCT
+			DISTINCTROW DIV DOUBLE DROP DUAL EACH ELSE ELSEIF ENCLOSED ESCAPED EXISTS
+			EXIT EXPLAIN FALSE FETCH FLOAT FLOAT4 FLOAT8 FOR FORCE FOREIGN FROM
+			FULLTEXT GRANT GROUP HAVING HIGH_PRIORITY HOUR_MICROSECOND HOUR_MINUTE
+			HOUR_SECOND IF IGNORE IN INDEX INFILE INNER INOUT INSENSITIVE INSERT INT
+			INT1 INT2 INT3 INT4 INT8 INTEGER INTERVAL INTO IS ITERATE JOIN KEY KEYS
+			KILL LEADING LEAVE LEFT LIKE LIMIT LINEAR LINES LOAD LOCALTIME
+			LOCALTIMESTAMP LOCK LONG LONGBLOB LONGTEXT LOOP LOW_PRIORITY
+			MASTER_SSL_VERIFY_SERVER_CERT MATCH MAXVALUE MEDIUMBLOB MEDIUMINT
+			MEDIUMTEXT MIDDLEINT MINUTE_MICROSECOND MINUTE_SECOND MOD MODIFIES NATURAL
+			NOT NO_WRITE_TO_BINLOG NULL NUMERIC ON OPTIMIZE OPTION OPTIONALLY OR ORDER
+			OUT OUTER OUTFILE PRECISION PRIMARY PROCEDURE PURGE RANGE READ READS
+			READ_WRITE REAL REFERENCES REGEXP RELEASE RENAME REPEAT REPLACE REQUIRE
+			RESIGNAL RESTRICT RETURN REVOKE RIGHT RLIKE SCHEMA SCHEMAS
+			SECOND_MICROSECOND SELECT SENSITIVE SEPARATOR SET SHOW SIGNAL SMALLINT
+			SPATIAL SPECIFIC SQL SQLEXCEPTION SQLSTATE SQLWARNING SQL_BIG_RESULT
+			SQL_CALC_FOUND_ROWS SQL_SMALL_RESULT SSL STARTING STRAIGHT_JOIN TABLE
+			TERMINATED THEN TINYBLOB TINYINT TINYTEXT TO TRAILING TRIGGER TRUE UNDO
+			UNION UNIQUE UNLOCK UNSIGNED UPDATE USAGE USE USING UTC_DATE UTC_TIME
+			UTC_TIMESTAMP VALUES VARBINARY VARCHAR VARCHARACTER VARYING WHEN WHERE
+			WHILE WITH WRITE XOR YEAR_MONTH ZEROFILL`,
+		applies: func(tengo.Flavor) bool { return true },
+	},
+	{
+		words:   `GET IO_AFTER_GTIDS IO_BEFORE_GTIDS MASTER_BIND PARTITION`,
+		applies: func(fl tengo.Flavor) bool { return fl.MySQLishMinVersion(5, 6) },
+	},
+	{
+		words:   `GENERATED OPTIMIZER_COSTS STORED VIRTUAL`,
+		applies: func(fl tengo.Flavor) bool { return fl.MySQLishMinVersion(5, 7) },
+	},
+	{
+		words: `CUBE CUME_DIST DENSE_RANK EMPTY EXCEPT FIRST_VALUE FUNCTION GROUPING
+			GROUPS JSON_TABLE LAG LAST_VALUE LATERAL LEAD NTH_VALUE NTILE OF OVER
+			PERCENT_RANK RANK RECURSIVE ROW ROWS ROW_NUMBER SYSTEM WINDOW`,
+		applies: func(fl tengo.Flavor) bool { return fl.MySQLishMinVersion(8, 0) },
+	},
+	{
+		words: `DELETE_DOMAIN_ID DO_DOMAIN_IDS GENERAL IGNORE_DOMAIN_IDS
+			IGNORE_SERVER_IDS MASTER_HEARTBEAT_PERIOD PAGE_CHECKSUM PARSE_VCOL_EXPR
+			POSITION REF_SYSTEM_ID RETURNING SLOW STATS_AUTO_RECALC STATS_PERSISTENT
+			STATS_SAMPLE_PAGES`,
+		applies: func(fl tengo.Flavor) bool { return fl.Vendor == tengo.VendorMariaDB },
+	},
+	{
+		words:   `OVER RECURSIVE ROWS WINDOW`,
+		applies: func(fl tengo.Flavor) bool { return fl.VendorMinVersion(tengo.VendorMariaDB, 10, 2) },
+	},
+	{
+		words:   `EXCEPT INTERSECT`,
+		applies: func(fl tengo.Flavor) bool { return fl.VendorMinVersion(tengo.VendorMariaDB, 10, 3) },
+	},
+}
+
+func init() {
+	for n := range reservedWordGroups {
+		group := &reservedWordGroups[n]
+		group.wordSet = make(map[string]bool)
+		for _, word := range strings.Fields(group.words) {
+			group.wordSet[word] = true
+		}
+	}
+}
+
+// isReservedWord returns true if word is a reserved word in flavor. If flavor
+// is not known, words reserved in any supported flavor are considered to be
+// reserved.
+func isReservedWord(word string, flavor tengo.Flavor) bool {
+	word = strings.ToUpper(word)
+	for _, group := range reservedWordGroups {
+		if group.wordSet[word] && (!flavor.Known() || group.applies(flavor)) {
+			return true
+		}
+	}
+	return false
+}
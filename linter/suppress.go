@@ -0,0 +1,109 @@
+package linter
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/skeema/skeema/fs"
+)
+
+// suppression represents a single problem name listed in a skeema:disable
+// comment. Suppressions may apply to an entire statement, or just one line of
+// a statement.
+type suppression struct {
+	comment    *fs.Statement // statement containing the comment
+	lineOffset int           // line of the comment within comment.Text
+	target     *fs.Statement // statement whose annotations are suppressed
+	line       int           // line offset within target.Text, or -1 if entire statement is suppressed
+	problem    string
+	used       bool
+}
+
+// reSuppression matches a skeema:disable comment, which may be a single-line
+// comment using -- or #, or a C-style comment.
+var reSuppression = regexp.MustCompile(`(?i)(--\s|#|/\*)\s*skeema:disable\s+([^\n]*?)\s*(\*/)?\s*$`)
+
+// findSuppressions returns all suppressions in logicalSchema's CREATE
+// statements. A skeema:disable comment within a CREATE affects the entire
+// statement if the comment is on its own line, or just one line if the comment
+// trails other text on that line. A skeema:disable comment in the whitespace
+// and comments immediately preceding a CREATE affects the entire statement.
+func findSuppressions(logicalSchema *fs.LogicalSchema) (result []*suppression) {
+	for _, stmt := range logicalSchema.Creates {
+		if prev := previousStatement(stmt); prev != nil && prev.Type == fs.StatementTypeNoop {
+			for _, s := range parseSuppressions(prev) {
+				s.target, s.line = stmt, -1
+				result = append(result, s)
+			}
+		}
+		for _, s := range parseSuppressions(stmt) {
+			s.target = stmt
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// parseSuppressions returns suppressions for all skeema:disable comments in
+// stmt. The returned values do not have their target set. The line field is
+// set to -1 if the comment is on a line by itself, or to the comment's line
+// otherwise.
+func parseSuppressions(stmt *fs.Statement) (result []*suppression) {
+	for lineOffset, line := range strings.Split(stmt.Text, "\n") {
+		loc := reSuppression.FindStringSubmatchIndex(line)
+		if loc == nil {
+			continue
+		}
+		s := suppression{comment: stmt, lineOffset: lineOffset, line: -1}
+		if strings.TrimSpace(line[0:loc[2]]) != "" {
+			s.line = lineOffset
+		}
+		names := strings.FieldsFunc(line[loc[4]:loc[5]], func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+		for _, name := range names {
+			copied := s
+			copied.problem = strings.ToLower(name)
+			result = append(result, &copied)
+		}
+	}
+	return result
+}
+
+// previousStatement returns the statement immediately preceding stmt in its
+// file, or nil if there is no such statement.
+func previousStatement(stmt *fs.Statement) *fs.Statement {
+	if stmt.FromFile == nil {
+		return nil
+	}
+	for n, other := range stmt.FromFile.Statements {
+		if other == stmt && n > 0 {
+			return stmt.FromFile.Statements[n-1]
+		}
+	}
+	return nil
+}
+
+// hasInlineSuppression returns true if any of suppressions come from a comment
+// within stmt itself.
+func hasInlineSuppression(stmt *fs.Statement, suppressions []*suppression) bool {
+	for _, s := range suppressions {
+		if s.comment == stmt {
+			return true
+		}
+	}
+	return false
+}
+
+// suppress returns true if any of suppressions applies to a. Any matching
+// suppressions are marked as used.
+func suppress(a *Annotation, suppressions []*suppression) (suppressed bool) {
+	for _, s := range suppressions {
+		if s.target == a.Statement && s.problem == a.Problem && (s.line == -1 || s.line == a.LineOffset) {
+			s.used = true
+			suppressed = true
+		}
+	}
+	return suppressed
+}
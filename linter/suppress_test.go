@@ -0,0 +1,81 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestSuppressions(t *testing.T) {
+	comment := &fs.Statement{
+		Text: "-- This table is legacy\n-- skeema:disable no-pk, has-float\n",
+		Type: fs.StatementTypeNoop,
+	}
+	create := &fs.Statement{
+		Text: "CREATE TABLE t (\n" +
+			"  -- skeema:disable bad-charset\n" +
+			"  a float, # skeema:disable has-float\n" +
+			"  b int /* skeema:disable has-enum bad-engine */\n" +
+			") ENGINE=InnoDB;\n",
+		Type:       fs.StatementTypeCreate,
+		ObjectType: tengo.ObjectTypeTable,
+		ObjectName: "t",
+	}
+	other := &fs.Statement{
+		Text:       "CREATE TABLE other (a int) ENGINE=InnoDB; -- skeema:disable\n",
+		Type:       fs.StatementTypeCreate,
+		ObjectType: tengo.ObjectTypeTable,
+		ObjectName: "other",
+	}
+	fs.NewTokenizedSQLFile(fs.SQLFile{}, []*fs.Statement{comment, create, other})
+	logicalSchema := &fs.LogicalSchema{
+		Creates: map[tengo.ObjectKey]*fs.Statement{
+			create.ObjectKey(): create,
+			other.ObjectKey():  other,
+		},
+	}
+
+	suppressions := findSuppressions(logicalSchema)
+	expected := []suppression{
+		{comment: comment, lineOffset: 1, target: create, line: -1, problem: "no-pk"},
+		{comment: comment, lineOffset: 1, target: create, line: -1, problem: "has-float"},
+		{comment: create, lineOffset: 1, target: create, line: -1, problem: "bad-charset"},
+		{comment: create, lineOffset: 2, target: create, line: 2, problem: "has-float"},
+		{comment: create, lineOffset: 3, target: create, line: 3, problem: "has-enum"},
+		{comment: create, lineOffset: 3, target: create, line: 3, problem: "bad-engine"},
+	}
+	if len(suppressions) != len(expected) {
+		t.Fatalf("Expected %d suppressions, instead found %d", len(expected), len(suppressions))
+	}
+	for n, s := range suppressions {
+		if *s != expected[n] {
+			t.Errorf("Suppression[%d]: expected %+v, found %+v", n, expected[n], *s)
+		}
+	}
+	if !hasInlineSuppression(create, suppressions) || hasInlineSuppression(other, suppressions) {
+		t.Error("Unexpected result from hasInlineSuppression")
+	}
+
+	cases := []struct {
+		a        *Annotation
+		expected bool
+	}{
+		{&Annotation{Statement: create, Problem: "no-pk"}, true},
+		{&Annotation{Statement: other, Problem: "no-pk"}, false},
+		{&Annotation{Statement: create, Problem: "has-float", LineOffset: 2}, true},
+		{&Annotation{Statement: create, Problem: "has-enum", LineOffset: 2}, false},
+		{&Annotation{Statement: create, Problem: "has-enum", LineOffset: 3}, true},
+		{&Annotation{Statement: create, Problem: "has-fk"}, false},
+	}
+	for _, c := range cases {
+		if actual := suppress(c.a, suppressions); actual != c.expected {
+			t.Errorf("Expected suppress() of %s at line offset %d to return %t, instead found %t", c.a.Problem, c.a.LineOffset, c.expected, actual)
+		}
+	}
+	for n, s := range suppressions {
+		if expectUsed := (n != 2 && n != 5); s.used != expectUsed {
+			t.Errorf("Suppression[%d]: expected used=%t, found %t", n, expectUsed, s.used)
+		}
+	}
+}
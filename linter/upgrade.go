@@ -0,0 +1,144 @@
+package linter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/workspace"
+	"github.com/skeema/tengo"
+)
+
+// CheckUpgrade executes each logical schema of dir in a workspace of a
+// different flavor, as specified by targetOpts, and compares the results to
+// schemas previously obtained from LintDir(dir) using currentFlavor.
+// Annotations are returned for statements which fail in the target flavor,
+// objects whose canonical CREATE changes, names which become reserved words,
+// use of features removed in the target flavor, and tables or columns whose
+// collation changes due to different defaults, if the corresponding upgrade-*
+// problem is enabled and not suppressed.
+func CheckUpgrade(dir *fs.Dir, targetOpts workspace.Options, currentFlavor tengo.Flavor, schemas map[string]*tengo.Schema) *Result {
+	opts, err := OptionsForDir(dir)
+	if err != nil {
+		return BadConfigResult(dir, err)
+	}
+	result := &Result{}
+	targetFlavor := targetOpts.Flavor
+	for _, logicalSchema := range dir.LogicalSchemas {
+		current := schemas[schemaKey(dir, logicalSchema)]
+		if current == nil {
+			continue
+		}
+		target, statementErrors, err := workspace.ExecLogicalSchema(logicalSchema, targetOpts)
+		if err != nil {
+			result.Exceptions = append(result.Exceptions, fmt.Errorf("Unable to execute schema in %s workspace: %s", targetFlavor, err))
+			continue
+		}
+		annotations := upgradeAnnotations(current, target, logicalSchema, currentFlavor, targetFlavor)
+		for _, stmtErr := range statementErrors {
+			annotations = append(annotations, &Annotation{
+				Statement: stmtErr.Statement,
+				Problem:   "upgrade-sql-error",
+				Summary:   "Statement fails in target flavor",
+				Message:   fmt.Sprintf("In %s, this statement fails: %s", targetFlavor, strings.Replace(stmtErr.Err.Error(), "Error executing DDL in workspace: ", "", 1)),
+			})
+		}
+		suppressions := findSuppressions(logicalSchema)
+		for _, a := range annotations {
+			if key := a.Statement.ObjectKey(); opts.ShouldIgnore(key) {
+				result.DebugLogs = append(result.DebugLogs, fmt.Sprintf("Skipping %s because ignore-table='%s'", key, opts.IgnoreTable))
+			} else {
+				result.addDeferred(a, opts, suppressions)
+			}
+		}
+	}
+	result.SortByFile()
+	return result
+}
+
+// upgradeAnnotations compares current, a schema introspected from a workspace
+// of flavor from, to target, the same logical schema introspected from a
+// workspace of flavor to. Objects missing from target are not compared, since
+// they failed to be created in the target flavor.
+func upgradeAnnotations(current, target *tengo.Schema, logicalSchema *fs.LogicalSchema, from, to tengo.Flavor) (results []*Annotation) {
+	annotate := func(stmt *fs.Statement, lineOffset int, problem, summary, message string) {
+		results = append(results, &Annotation{
+			Statement:  stmt,
+			LineOffset: lineOffset,
+			Problem:    problem,
+			Summary:    summary,
+			Message:    message,
+		})
+	}
+	checkName := func(stmt *fs.Statement, lineOffset int, objectType, name string) {
+		if isReservedWord(name, to) && !isReservedWord(name, from) {
+			annotate(stmt, lineOffset, "upgrade-reserved-word", "Name becomes a reserved word",
+				fmt.Sprintf("The name of %s %s is a reserved word in %s. It must be quoted with backticks in all queries.", objectType, name, to))
+		}
+	}
+
+	// Names, removed features, and collation changes
+	targetTables := target.TablesByName()
+	for _, table := range current.Tables {
+		stmt := logicalSchema.Creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: table.Name}]
+		checkName(stmt, 0, "table", table.Name)
+		targetTable := targetTables[table.Name]
+		var targetCols map[string]*tengo.Column
+		if targetTable != nil {
+			targetCols = targetTable.ColumnsByName()
+			if table.Collation != targetTable.Collation {
+				annotate(stmt, 0, "upgrade-collation-change", "Collation changes in target flavor",
+					fmt.Sprintf("Table %s has default collation %s, but in %s it will have default collation %s. Specify a COLLATE clause explicitly to retain the current behavior.", table.Name, table.Collation, to, targetTable.Collation))
+			}
+		}
+		for _, col := range table.Columns {
+			lineOffset := columnLineOffset(col, stmt)
+			checkName(stmt, lineOffset, "column", col.Name)
+			if col.Default.Quoted && strings.HasPrefix(col.Default.Value, "0000-00-00") {
+				annotate(stmt, lineOffset, "upgrade-removed-feature", "Zero date default",
+					fmt.Sprintf("Column %s of table %s has a zero date default, which is rejected by the default strict sql_mode and NO_ZERO_DATE in %s. Use NULL or a valid date as the default instead.", col.Name, table.Name, to))
+			}
+			if targetCol := targetCols[col.Name]; targetCol != nil && col.Collation != targetCol.Collation && table.Collation == targetTable.Collation {
+				annotate(stmt, lineOffset, "upgrade-collation-change", "Collation changes in target flavor",
+					fmt.Sprintf("Column %s of table %s has collation %s, but in %s it will have collation %s. Specify a COLLATE clause explicitly to retain the current behavior.", col.Name, table.Name, col.Collation, to, targetCol.Collation))
+			}
+		}
+		for _, idx := range table.SecondaryIndexes {
+			checkName(stmt, findFirstLineOffset(indexDefinitionRegexp(idx), stmt.Text), "index", idx.Name)
+		}
+	}
+	for _, routine := range current.Routines {
+		stmt := logicalSchema.Creates[tengo.ObjectKey{Type: routine.Type, Name: routine.Name}]
+		checkName(stmt, 0, string(routine.Type), routine.Name)
+	}
+
+	// Changes in canonical format
+	targetDefs := target.ObjectDefinitions()
+	for key, currentDef := range current.ObjectDefinitions() {
+		targetDef, ok := targetDefs[key]
+		if !ok || currentDef == targetDef {
+			continue
+		}
+		stmt := logicalSchema.Creates[key]
+		currentLines, targetLines := strings.Split(currentDef, "\n"), strings.Split(targetDef, "\n")
+		var n int
+		for n < len(currentLines) && n < len(targetLines) && currentLines[n] == targetLines[n] {
+			n++
+		}
+		var change string
+		if n < len(currentLines) && n < len(targetLines) {
+			change = fmt.Sprintf("%s becomes %s", strings.TrimSpace(currentLines[n]), strings.TrimSpace(targetLines[n]))
+		} else {
+			change = "the number of lines differs"
+		}
+		// The line offset is only meaningful if the statement is already in its
+		// canonical format for the current flavor
+		lineOffset := n
+		if stmt.Body() != currentDef || n >= len(currentLines) {
+			lineOffset = 0
+		}
+		annotate(stmt, lineOffset, "upgrade-canonical-change", "Canonical format changes in target flavor",
+			fmt.Sprintf("In %s, SHOW CREATE for %s will differ from the current canonical format, which will cause `skeema pull` to rewrite it; first difference: %s", to, key, change))
+	}
+	return results
+}
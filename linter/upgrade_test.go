@@ -0,0 +1,61 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/tengo"
+)
+
+func TestUpgradeAnnotations(t *testing.T) {
+	makeTable := func(collation, idType string) *tengo.Table {
+		cols := []*tengo.Column{
+			{Name: "id", TypeInDB: idType, Default: tengo.ColumnDefaultNull},
+			{Name: "rank", TypeInDB: "int(11)", Nullable: true, Default: tengo.ColumnDefaultNull},
+			{Name: "name", TypeInDB: "varchar(30)", CharSet: "utf8mb4", Collation: collation, Default: tengo.ColumnDefaultNull},
+			{Name: "created", TypeInDB: "datetime", Default: tengo.ColumnDefaultValue("0000-00-00 00:00:00")},
+		}
+		table := &tengo.Table{
+			Name:       "t",
+			Engine:     "InnoDB",
+			CharSet:    "utf8mb4",
+			Collation:  collation,
+			Columns:    cols,
+			PrimaryKey: &tengo.Index{Name: "PRIMARY", Columns: cols[0:1], SubParts: []uint16{0}, PrimaryKey: true, Unique: true},
+		}
+		table.CreateStatement = table.GeneratedCreateStatement(tengo.NewFlavor("mysql:5.7"))
+		return table
+	}
+	current := &tengo.Schema{Name: "_skeema_tmp", Tables: []*tengo.Table{makeTable("utf8mb4_general_ci", "int(10) unsigned")}}
+	target := &tengo.Schema{Name: "_skeema_tmp", Tables: []*tengo.Table{makeTable("utf8mb4_0900_ai_ci", "int unsigned")}}
+	stmt := &fs.Statement{Text: current.Tables[0].CreateStatement}
+	logicalSchema := &fs.LogicalSchema{
+		Creates: map[tengo.ObjectKey]*fs.Statement{{Type: tengo.ObjectTypeTable, Name: "t"}: stmt},
+	}
+
+	// Map of problem name -> expected line offset
+	expected := map[string]int{
+		"upgrade-reserved-word":    2, // rank
+		"upgrade-removed-feature":  4,
+		"upgrade-collation-change": 0,
+		"upgrade-canonical-change": 1,
+	}
+	annotations := upgradeAnnotations(current, target, logicalSchema, tengo.NewFlavor("mysql:5.7"), tengo.NewFlavor("mysql:8.0"))
+	if len(annotations) != len(expected) {
+		t.Errorf("Expected %d annotations, instead found %d: %+v", len(expected), len(annotations), annotations)
+	}
+	for _, a := range annotations {
+		if lineOffset, ok := expected[a.Problem]; !ok {
+			t.Errorf("Unexpected annotation %s: %s", a.Problem, a.Message)
+		} else if a.LineOffset != lineOffset {
+			t.Errorf("Expected annotation %s to have line offset %d, instead found %d", a.Problem, lineOffset, a.LineOffset)
+		}
+	}
+
+	// Objects missing from the target schema, i.e. that failed to be created,
+	// are only checked for names and removed features
+	annotations = upgradeAnnotations(current, &tengo.Schema{}, logicalSchema, tengo.NewFlavor("mysql:5.7"), tengo.NewFlavor("mysql:8.0"))
+	if len(annotations) != 2 {
+		t.Errorf("Expected 2 annotations, instead found %d: %+v", len(annotations), annotations)
+	}
+}
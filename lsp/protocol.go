@@ -0,0 +1,196 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// This file contains the subset of Language Server Protocol types used by
+// Server, along with JSON-RPC message framing. Field names match the protocol
+// specification.
+
+// Position is a zero-based line and character offset within a document. The
+// character offset is measured in UTF-16 code units.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a span between two positions in a document. The end position is
+// exclusive.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// contains returns true if pos falls within r.
+func (r Range) contains(pos Position) bool {
+	return !before(pos, r.Start) && before(pos, r.End)
+}
+
+// overlaps returns true if r and other share at least one position. An empty
+// range overlaps any range that contains its position.
+func (r Range) overlaps(other Range) bool {
+	if other.Start == other.End {
+		return r.contains(other.Start)
+	}
+	return before(r.Start, other.End) && before(other.Start, r.End)
+}
+
+// before returns true if a is strictly before b.
+func before(a, b Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
+
+// Location is a range within a specific document.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// Constants enumerating diagnostic severities
+const (
+	DiagnosticSeverityError       = 1
+	DiagnosticSeverityWarning     = 2
+	DiagnosticSeverityInformation = 3
+)
+
+// Diagnostic is a problem reported in a document.
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Code     string `json:"code,omitempty"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// TextEdit replaces the text in Range with NewText.
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// WorkspaceEdit is a set of edits, keyed by document URI.
+type WorkspaceEdit struct {
+	Changes map[string][]TextEdit `json:"changes"`
+}
+
+// CodeAction is an edit which may be applied by the user.
+type CodeAction struct {
+	Title       string         `json:"title"`
+	Kind        string         `json:"kind"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
+	Edit        *WorkspaceEdit `json:"edit"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type codeActionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+type showMessageParams struct {
+	Type    int    `json:"type"`
+	Message string `json:"message"`
+}
+
+// request is an incoming JSON-RPC request or notification. Notifications have
+// no ID.
+type request struct {
+	ID     *json.RawMessage `json:"id"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+}
+
+// Constants enumerating JSON-RPC error codes used by Server
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// readMessage reads a single JSON-RPC message body from r, which must be
+// preceded by a header section containing Content-Length.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		colon := strings.IndexByte(line, ':')
+		if colon < 0 {
+			return nil, fmt.Errorf("Malformed header line %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(line[:colon]), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(line[colon+1:])); err != nil {
+				return nil, fmt.Errorf("Malformed Content-Length header %q", line)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, errors.New("Message is missing Content-Length header")
+	}
+	body := make([]byte, length)
+	_, err := io.ReadFull(r, body)
+	return body, err
+}
+
+// writeMessage writes v to w as a JSON-RPC message body, preceded by a
+// Content-Length header.
+func writeMessage(w io.Writer, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+// uriToPath converts a file URI to an absolute filesystem path.
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	} else if u.Scheme != "file" {
+		return "", fmt.Errorf("Unsupported URI scheme in %s", uri)
+	}
+	return filepath.FromSlash(u.Path), nil
+}
+
+// pathToURI converts an absolute filesystem path to a file URI.
+func pathToURI(path string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
+
+// utf16Len returns the length of s in UTF-16 code units, which is how LSP
+// measures character offsets.
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
@@ -0,0 +1,411 @@
+// Package lsp implements a Language Server Protocol server for *.sql files
+// managed by Skeema, reporting linter annotations as diagnostics.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/linter"
+	"github.com/skeema/tengo"
+)
+
+// Server is a Language Server Protocol server for a single client. Requests
+// are handled one at a time, in the order received, so linting a dir blocks
+// handling of subsequent requests until it completes.
+type Server struct {
+	parseDir func(dirPath string) (*fs.Dir, error)
+	lint     func(dir *fs.Dir) *linter.Result
+	in       *bufio.Reader
+	out      io.Writer
+	writeErr error
+	dirs     map[string]*dirState // keyed by dir path
+	shutdown bool
+}
+
+// dirState tracks the most recent lint result for a dir, along with the files
+// which have had diagnostics published.
+type dirState struct {
+	dir       *fs.Dir
+	result    *linter.Result
+	published map[string]bool
+}
+
+// rpcError is a JSON-RPC error returned in response to a request.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewServer returns a server which reads requests from in, and writes
+// responses and notifications to out. parseDir is used to parse the dir
+// containing each *.sql file, and lint is used to lint that dir whenever one
+// of its files is opened or saved. Annotation line offsets returned by lint
+// must be relative to the statement text on disk, meaning that statements
+// must not be normalized.
+func NewServer(in io.Reader, out io.Writer, parseDir func(dirPath string) (*fs.Dir, error), lint func(dir *fs.Dir) *linter.Result) *Server {
+	return &Server{
+		parseDir: parseDir,
+		lint:     lint,
+		in:       bufio.NewReader(in),
+		out:      out,
+		dirs:     make(map[string]*dirState),
+	}
+}
+
+// Run handles requests until the client sends an exit notification. An error
+// is returned if the client did not request a shutdown prior to exiting or
+// closing the connection, or if a message cannot be read or written.
+func (s *Server) Run() error {
+	for {
+		body, err := readMessage(s.in)
+		if err == io.EOF && s.shutdown {
+			return nil
+		} else if err == io.EOF {
+			return errors.New("Connection closed before shutdown request")
+		} else if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.respond(nil, nil, &rpcError{Code: codeParseError, Message: err.Error()})
+		} else if req.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("Received exit notification before shutdown request")
+			}
+			return nil
+		} else if result, rerr := s.handle(req); req.ID != nil {
+			s.respond(req.ID, result, rerr)
+		}
+		if s.writeErr != nil {
+			return s.writeErr
+		}
+	}
+}
+
+// handle processes a single request or notification, returning the result to
+// send in response. Unknown notifications are ignored.
+func (s *Server) handle(req request) (interface{}, *rpcError) {
+	var params textDocumentPositionParams
+	var path string
+	if strings.HasPrefix(req.Method, "textDocument/") {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		var err error
+		if path, err = uriToPath(params.TextDocument.URI); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+	}
+
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": map[string]interface{}{
+					"openClose": true,
+					"change":    0, // document contents are read from disk, so changes aren't synced
+					"save":      map[string]bool{"includeText": false},
+				},
+				"codeActionProvider": true,
+				"definitionProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "skeema"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		s.lintFile(path, false)
+		return nil, nil
+	case "textDocument/didSave":
+		s.lintFile(path, true)
+		return nil, nil
+	case "textDocument/codeAction":
+		var caParams codeActionParams
+		if err := json.Unmarshal(req.Params, &caParams); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		return s.codeActions(path, caParams.Range), nil
+	case "textDocument/definition":
+		return s.definition(path, params.Position), nil
+	}
+	if req.ID == nil {
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("Method %s is not supported", req.Method)}
+}
+
+func (s *Server) respond(id *json.RawMessage, result interface{}, rerr *rpcError) {
+	msg := map[string]interface{}{"jsonrpc": "2.0", "id": id}
+	if rerr != nil {
+		msg["error"] = rerr
+	} else {
+		msg["result"] = result
+	}
+	s.write(msg)
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	s.write(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// write sends msg to the client. After a write fails, further writes are
+// skipped, and Run returns the error.
+func (s *Server) write(msg interface{}) {
+	if s.writeErr == nil {
+		s.writeErr = writeMessage(s.out, msg)
+	}
+}
+
+// showError displays an error message in the client.
+func (s *Server) showError(message string) {
+	s.notify("window/showMessage", showMessageParams{Type: 1, Message: message})
+}
+
+// lintFile lints the dir containing path, unless it was already linted and
+// force is false, and then publishes diagnostics for all of the dir's files.
+func (s *Server) lintFile(path string, force bool) {
+	if filepath.Ext(path) != ".sql" {
+		return
+	}
+	dirPath := filepath.Dir(path)
+	state := s.dirs[dirPath]
+	if state != nil && !force {
+		return
+	}
+	dir, err := s.parseDir(dirPath)
+	if err != nil {
+		s.showError(fmt.Sprintf("Unable to parse %s: %s", dirPath, err))
+		return
+	}
+	if state == nil {
+		state = &dirState{published: make(map[string]bool)}
+		s.dirs[dirPath] = state
+	}
+	state.dir = dir
+	state.result = s.lint(dir)
+	for _, err := range state.result.Exceptions {
+		s.showError(fmt.Sprintf("Unable to lint %s: %s", dirPath, err))
+	}
+	s.publish(state)
+}
+
+// publish sends diagnostics for every file with annotations in state's lint
+// result. Files in the dir without annotations, as well as files which
+// previously had diagnostics published, are sent an empty list, to clear any
+// stale diagnostics.
+func (s *Server) publish(state *dirState) {
+	byFile := make(map[string][]Diagnostic)
+	for _, sf := range state.dir.SQLFiles {
+		byFile[sf.Path()] = []Diagnostic{}
+	}
+	for path := range state.published {
+		byFile[path] = []Diagnostic{}
+	}
+	eachAnnotation(state.result, func(a *linter.Annotation, severity int) {
+		if a.Statement.File != "" {
+			byFile[a.Statement.File] = append(byFile[a.Statement.File], annotationDiagnostic(a, severity))
+		}
+	})
+
+	paths := make([]string, 0, len(byFile))
+	for path := range byFile {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: pathToURI(path), Diagnostics: byFile[path]})
+		state.published[path] = true
+	}
+}
+
+// codeActions returns an action for each annotation with a fix, in any
+// statement of the file at path which overlaps rng. Format notices have the
+// statement's canonical format as their fix.
+func (s *Server) codeActions(path string, rng Range) []CodeAction {
+	actions := []CodeAction{}
+	state := s.dirs[filepath.Dir(path)]
+	if state == nil {
+		return actions
+	}
+	eachAnnotation(state.result, func(a *linter.Annotation, severity int) {
+		if a.Fix == "" || a.Statement.File != path {
+			return
+		}
+		stmtRange := statementRange(a.Statement)
+		if !stmtRange.overlaps(rng) {
+			return
+		}
+		title := fmt.Sprintf("Fix %s in %s", a.Problem, a.Statement.ObjectKey())
+		if a.Problem == "" {
+			title = fmt.Sprintf("Reformat %s to canonical format", a.Statement.ObjectKey())
+		}
+		actions = append(actions, CodeAction{
+			Title:       title,
+			Kind:        "quickfix",
+			Diagnostics: []Diagnostic{annotationDiagnostic(a, severity)},
+			Edit: &WorkspaceEdit{
+				Changes: map[string][]TextEdit{
+					pathToURI(path): {{Range: stmtRange, NewText: a.Fix}},
+				},
+			},
+		})
+	})
+	return actions
+}
+
+var reReferences = regexp.MustCompile("(?i)\\breferences\\s+(?:(`[^`]+`|[\\w$]+)\\s*\\.\\s*)?(`[^`]+`|[\\w$]+)")
+
+// definition returns the location of the CREATE TABLE statement for the table
+// referenced by a foreign key, if pos is within the referenced table name of a
+// foreign key in the file at path. Otherwise, nil is returned.
+func (s *Server) definition(path string, pos Position) *Location {
+	var dir *fs.Dir
+	if state := s.dirs[filepath.Dir(path)]; state != nil {
+		dir = state.dir
+	} else if parsed, err := s.parseDir(filepath.Dir(path)); err == nil {
+		dir = parsed
+	} else {
+		return nil
+	}
+	for _, logicalSchema := range dir.LogicalSchemas {
+		for _, stmt := range logicalSchema.Creates {
+			if stmt.File != path || stmt.ObjectType != tengo.ObjectTypeTable || !statementRange(stmt).contains(pos) {
+				continue
+			}
+			for _, match := range reReferences.FindAllStringSubmatchIndex(stmt.Text, -1) {
+				start := match[4]
+				if match[2] > -1 {
+					start = match[2]
+				}
+				if !(Range{Start: textPosition(stmt, start), End: textPosition(stmt, match[5])}).contains(pos) {
+					continue
+				}
+				var schemaName string
+				if match[2] > -1 {
+					schemaName = strings.Trim(stmt.Text[match[2]:match[3]], "`")
+				}
+				tableName := strings.Trim(stmt.Text[match[4]:match[5]], "`")
+				if target := s.findTable(dir, logicalSchema, schemaName, tableName); target != nil {
+					return &Location{URI: pathToURI(target.File), Range: statementRange(target)}
+				}
+				return nil
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// findTable returns the CREATE TABLE statement for the named table. If
+// schemaName is empty or maps to dir, the table is looked up in
+// logicalSchema. Otherwise, sibling dirs of dir are searched for one mapping
+// to schemaName via the schema option.
+func (s *Server) findTable(dir *fs.Dir, logicalSchema *fs.LogicalSchema, schemaName, tableName string) *fs.Statement {
+	key := tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: tableName}
+	if schemaName == "" || hasSchemaName(dir, schemaName) {
+		return logicalSchema.Creates[key]
+	}
+	parent, err := s.parseDir(filepath.Dir(dir.Path))
+	if err != nil {
+		return nil
+	}
+	subdirs, _, err := parent.Subdirs()
+	if err != nil {
+		return nil
+	}
+	for _, sub := range subdirs {
+		if sub.Path == dir.Path || !hasSchemaName(sub, schemaName) {
+			continue
+		}
+		for _, ls := range sub.LogicalSchemas {
+			if stmt := ls.Creates[key]; stmt != nil {
+				return stmt
+			}
+		}
+	}
+	return nil
+}
+
+// hasSchemaName returns true if dir's schema option lists name.
+func hasSchemaName(dir *fs.Dir, name string) bool {
+	for _, schemaName := range dir.Config.GetSlice("schema", ',', true) {
+		if schemaName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// eachAnnotation calls fn for each error, warning, and format notice in
+// result, along with its corresponding diagnostic severity.
+func eachAnnotation(result *linter.Result, fn func(a *linter.Annotation, severity int)) {
+	for _, a := range result.Errors {
+		fn(a, DiagnosticSeverityError)
+	}
+	for _, a := range result.Warnings {
+		fn(a, DiagnosticSeverityWarning)
+	}
+	for _, a := range result.FormatNotices {
+		fn(a, DiagnosticSeverityInformation)
+	}
+}
+
+// annotationDiagnostic converts a to a diagnostic. The diagnostic's range
+// spans the annotation's entire line, starting at the statement's first
+// character if the annotation is on the statement's first line. Format
+// notices have no message, so their summary is used instead.
+func annotationDiagnostic(a *linter.Annotation, severity int) Diagnostic {
+	message := a.Message
+	if message == "" {
+		message = a.Summary
+	}
+	var start Position
+	if a.LineOffset == 0 {
+		start = textPosition(a.Statement, 0)
+	} else {
+		start = Position{Line: a.LineNo() - 1}
+	}
+	return Diagnostic{
+		Range:    Range{Start: start, End: Position{Line: start.Line + 1}},
+		Severity: severity,
+		Code:     a.Problem,
+		Source:   "skeema",
+		Message:  message,
+	}
+}
+
+// statementRange returns the range of stmt's body within its file, excluding
+// any trailing delimiter and whitespace.
+func statementRange(stmt *fs.Statement) Range {
+	return Range{
+		Start: textPosition(stmt, 0),
+		End:   textPosition(stmt, len(stmt.Body())),
+	}
+}
+
+// textPosition converts a byte offset within stmt.Text to a position within
+// stmt's file.
+func textPosition(stmt *fs.Statement, offset int) Position {
+	prefix := stmt.Text[:offset]
+	pos := Position{
+		Line:      stmt.LineNo - 1 + strings.Count(prefix, "\n"),
+		Character: utf16Len(prefix[strings.LastIndexByte(prefix, '\n')+1:]),
+	}
+	if pos.Line < 0 {
+		pos.Line = 0
+	}
+	if pos.Line == stmt.LineNo-1 && stmt.CharNo > 0 {
+		pos.Character += stmt.CharNo - 1
+	}
+	return pos
+}
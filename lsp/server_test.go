@@ -0,0 +1,255 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/linter"
+	"github.com/skeema/skeema/util"
+	"github.com/skeema/tengo"
+)
+
+func TestReadWriteMessage(t *testing.T) {
+	var buf bytes.Buffer
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": "test", "params": []string{"ünïcode"}}
+	if err := writeMessage(&buf, msg); err != nil {
+		t.Fatalf("Unexpected error from writeMessage: %s", err)
+	}
+	if err := writeMessage(&buf, msg); err != nil {
+		t.Fatalf("Unexpected error from writeMessage: %s", err)
+	}
+	r := bufio.NewReader(&buf)
+	for n := 0; n < 2; n++ {
+		body, err := readMessage(r)
+		if err != nil {
+			t.Fatalf("Unexpected error from readMessage: %s", err)
+		}
+		if string(body) != `{"jsonrpc":"2.0","method":"test","params":["ünïcode"]}` {
+			t.Errorf("Unexpected body from readMessage: %s", body)
+		}
+	}
+
+	for _, input := range []string{"Content-Type: foo\r\n\r\n{}", "Content-Length: x\r\n\r\n{}", "bogus\r\n\r\n"} {
+		if _, err := readMessage(bufio.NewReader(strings.NewReader(input))); err == nil {
+			t.Errorf("Expected error from readMessage on input %q, but err was nil", input)
+		}
+	}
+}
+
+func TestURIConversion(t *testing.T) {
+	path := "/some dir/file.sql"
+	uri := pathToURI(path)
+	if uri != "file:///some%20dir/file.sql" {
+		t.Errorf("Unexpected result from pathToURI: %s", uri)
+	}
+	if actual, err := uriToPath(uri); err != nil || actual != path {
+		t.Errorf("Unexpected result from uriToPath: %s, %v", actual, err)
+	}
+	if _, err := uriToPath("https://example.com/file.sql"); err == nil {
+		t.Error("Expected error from uriToPath with non-file URI, but err was nil")
+	}
+}
+
+func TestServer(t *testing.T) {
+	rootPath, err := ioutil.TempDir("", "lsptest")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(rootPath)
+	writeFile := func(relPath, contents string) string {
+		t.Helper()
+		path := filepath.Join(rootPath, relPath)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Unable to create dir: %s", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatalf("Unable to write file: %s", err)
+		}
+		return path
+	}
+	if err := os.Mkdir(filepath.Join(rootPath, ".git"), 0755); err != nil {
+		t.Fatalf("Unable to create dir: %s", err)
+	}
+	writeFile("app/.skeema", "schema=app\n")
+	ordersPath := writeFile("app/orders.sql", "CREATE TABLE orders (\n  id int unsigned NOT NULL,\n  user_id int unsigned NOT NULL,\n  member_id int unsigned NOT NULL,\n  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id),\n  CONSTRAINT fk_member FOREIGN KEY (member_id) REFERENCES `accounts`.`members` (id)\n);\n")
+	usersPath := writeFile("app/users.sql", "-- comment line\nCREATE TABLE users (id int unsigned NOT NULL);\n")
+	writeFile("accounts/.skeema", "schema=accounts\n")
+	membersPath := writeFile("accounts/members.sql", "CREATE TABLE members (id int unsigned NOT NULL);\n")
+
+	cmd := mybase.NewCommand("lsptest", "", "", nil)
+	util.AddGlobalOptions(cmd)
+	linter.AddCommandOptions(cmd)
+	cmd.AddArg("environment", "production", false)
+	cfg := mybase.ParseFakeCLI(t, cmd, "lsptest")
+	parseDir := func(dirPath string) (*fs.Dir, error) {
+		return fs.ParseDir(dirPath, cfg)
+	}
+	var lintCount int
+	lint := func(dir *fs.Dir) *linter.Result {
+		lintCount++
+		creates := dir.LogicalSchemas[0].Creates
+		return &linter.Result{
+			Errors: []*linter.Annotation{{
+				Statement:  creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "orders"}],
+				LineOffset: 2,
+				Problem:    "made-up-problem",
+				Message:    "Column user_id is problematic",
+			}},
+			FormatNotices: []*linter.Annotation{{
+				Statement: creates[tengo.ObjectKey{Type: tengo.ObjectTypeTable, Name: "users"}],
+				Summary:   "SQL statement should be reformatted",
+				Fix:       "CREATE TABLE `users` (\n  `id` int(10) unsigned NOT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=latin1",
+			}},
+		}
+	}
+
+	// Positions of the referenced table names in orders.sql
+	ordersLines := strings.Split(strings.Replace(readFile(t, ordersPath), "`", "", -1), "\n")
+	usersRef := Position{Line: 4, Character: strings.Index(ordersLines[4], "users (")}
+	membersRef := Position{Line: 5, Character: strings.Index(ordersLines[5], "members (") + 2} // +2 for backticks
+
+	var in bytes.Buffer
+	var nextID int
+	send := func(method string, params interface{}, isRequest bool) {
+		msg := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+		if isRequest {
+			nextID++
+			msg["id"] = nextID
+		}
+		if err := writeMessage(&in, msg); err != nil {
+			t.Fatalf("Unexpected error from writeMessage: %s", err)
+		}
+	}
+	doc := func(path string) map[string]string {
+		return map[string]string{"uri": pathToURI(path)}
+	}
+	send("initialize", map[string]interface{}{}, true)                                                                                                                                          // 1
+	send("initialized", map[string]interface{}{}, false)                                                                                                                                        //
+	send("textDocument/didOpen", map[string]interface{}{"textDocument": doc(ordersPath)}, false)                                                                                                //
+	send("textDocument/didOpen", map[string]interface{}{"textDocument": doc(usersPath)}, false)                                                                                                 // already linted
+	send("textDocument/codeAction", map[string]interface{}{"textDocument": doc(usersPath), "range": Range{}}, true)                                                                             // 2
+	send("textDocument/codeAction", map[string]interface{}{"textDocument": doc(usersPath), "range": Range{Start: Position{Line: 1, Character: 5}, End: Position{Line: 1, Character: 5}}}, true) // 3
+	send("textDocument/definition", map[string]interface{}{"textDocument": doc(ordersPath), "position": usersRef}, true)                                                                        // 4
+	send("textDocument/definition", map[string]interface{}{"textDocument": doc(ordersPath), "position": membersRef}, true)                                                                      // 5
+	send("textDocument/definition", map[string]interface{}{"textDocument": doc(ordersPath), "position": Position{Line: 1}}, true)                                                               // 6
+	send("textDocument/didSave", map[string]interface{}{"textDocument": doc(usersPath)}, false)                                                                                                 //
+	send("textDocument/hover", map[string]interface{}{"textDocument": doc(usersPath)}, true)                                                                                                    // 7
+	send("shutdown", nil, true)                                                                                                                                                                 // 8
+	send("exit", nil, false)
+
+	var out bytes.Buffer
+	server := NewServer(&in, &out, parseDir, lint)
+	if err := server.Run(); err != nil {
+		t.Fatalf("Unexpected error from Run: %s", err)
+	}
+	if lintCount != 2 {
+		t.Errorf("Expected dir to be linted twice, instead linted %d times", lintCount)
+	}
+
+	// Collect responses by ID, and diagnostics by URI
+	responses := make(map[int]json.RawMessage)
+	errorCodes := make(map[int]int)
+	diagnostics := make(map[string][]Diagnostic)
+	var publishCount int
+	r := bufio.NewReader(&out)
+	for {
+		body, err := readMessage(r)
+		if err != nil {
+			break
+		}
+		var msg struct {
+			ID     int                      `json:"id"`
+			Method string                   `json:"method"`
+			Params publishDiagnosticsParams `json:"params"`
+			Result json.RawMessage          `json:"result"`
+			Error  *rpcError                `json:"error"`
+		}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatalf("Unable to unmarshal %s: %s", body, err)
+		}
+		if msg.Method == "textDocument/publishDiagnostics" {
+			publishCount++
+			diagnostics[msg.Params.URI] = msg.Params.Diagnostics
+		} else if msg.Error != nil {
+			errorCodes[msg.ID] = msg.Error.Code
+		} else {
+			responses[msg.ID] = msg.Result
+		}
+	}
+	if publishCount != 4 {
+		t.Errorf("Expected 4 diagnostics notifications, instead found %d", publishCount)
+	}
+
+	if !strings.Contains(string(responses[1]), `"definitionProvider":true`) {
+		t.Errorf("Unexpected initialize result: %s", responses[1])
+	}
+	expectDiagnostics := map[string]Diagnostic{
+		ordersPath: {Range: Range{Start: Position{Line: 2}, End: Position{Line: 3}}, Severity: DiagnosticSeverityError, Code: "made-up-problem", Source: "skeema", Message: "Column user_id is problematic"},
+		usersPath:  {Range: Range{Start: Position{Line: 1}, End: Position{Line: 2}}, Severity: DiagnosticSeverityInformation, Source: "skeema", Message: "SQL statement should be reformatted"},
+	}
+	for path, expected := range expectDiagnostics {
+		if actual := diagnostics[pathToURI(path)]; len(actual) != 1 || actual[0] != expected {
+			t.Errorf("Unexpected diagnostics for %s: %+v", path, actual)
+		}
+	}
+
+	var actions []CodeAction
+	if err := json.Unmarshal(responses[2], &actions); err != nil || len(actions) != 0 {
+		t.Errorf("Expected no code actions outside of statement, instead found %s", responses[2])
+	}
+	if err := json.Unmarshal(responses[3], &actions); err != nil || len(actions) != 1 {
+		t.Fatalf("Expected 1 code action, instead found %s", responses[3])
+	}
+	edits := actions[0].Edit.Changes[pathToURI(usersPath)]
+	expectRange := Range{Start: Position{Line: 1}, End: Position{Line: 1, Character: 45}}
+	if len(edits) != 1 || edits[0].Range != expectRange || !strings.HasPrefix(edits[0].NewText, "CREATE TABLE `users`") {
+		t.Errorf("Unexpected code action edits: %+v", edits)
+	}
+
+	expectLocations := map[int]string{
+		4: fmt.Sprintf(`{"uri":"%s","range":{"start":{"line":1,"character":0},"end":{"line":1,"character":45}}}`, pathToURI(usersPath)),
+		5: fmt.Sprintf(`{"uri":"%s","range":{"start":{"line":0,"character":0},"end":{"line":0,"character":47}}}`, pathToURI(membersPath)),
+		6: "null",
+	}
+	for id, expected := range expectLocations {
+		if string(responses[id]) != expected {
+			t.Errorf("Unexpected definition response %d: expected %s, found %s", id, expected, responses[id])
+		}
+	}
+
+	if errorCodes[7] != codeMethodNotFound {
+		t.Errorf("Expected error response for unsupported method, instead found %s", responses[7])
+	}
+	if string(responses[8]) != "null" {
+		t.Errorf("Unexpected shutdown response: %s", responses[8])
+	}
+}
+
+func TestServerWithoutShutdown(t *testing.T) {
+	var in, out bytes.Buffer
+	writeMessage(&in, map[string]interface{}{"jsonrpc": "2.0", "method": "exit"})
+	if err := NewServer(&in, &out, nil, nil).Run(); err == nil {
+		t.Error("Expected error from Run with exit before shutdown, but err was nil")
+	}
+	if err := NewServer(&in, &out, nil, nil).Run(); err == nil {
+		t.Error("Expected error from Run with connection closed before shutdown, but err was nil")
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unable to read %s: %s", path, err)
+	}
+	return string(contents)
+}
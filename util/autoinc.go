@@ -0,0 +1,50 @@
+package util
+
+import (
+	"math"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// AutoIncrementMax returns the maximum value that may be stored in col, based
+// on its integer type. If col is not an integer column, ok will be false.
+func AutoIncrementMax(col *tengo.Column) (max uint64, ok bool) {
+	colType := strings.ToLower(col.TypeInDB)
+	unsigned := strings.Contains(colType, "unsigned")
+	if pos := strings.IndexAny(colType, "( "); pos > -1 {
+		colType = colType[0:pos]
+	}
+	var bits uint
+	switch colType {
+	case "tinyint":
+		bits = 8
+	case "smallint":
+		bits = 16
+	case "mediumint":
+		bits = 24
+	case "int", "integer":
+		bits = 32
+	case "bigint":
+		bits = 64
+	default:
+		return 0, false
+	}
+	if unsigned && bits == 64 {
+		return math.MaxUint64, true
+	} else if unsigned {
+		return (1 << bits) - 1, true
+	}
+	return (1 << (bits - 1)) - 1, true
+}
+
+// AutoIncrementColumn returns table's auto-increment column, or nil if the
+// table does not have one.
+func AutoIncrementColumn(table *tengo.Table) *tengo.Column {
+	for _, col := range table.Columns {
+		if col.AutoIncrement {
+			return col
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package util
+
+import (
+	"math"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestAutoIncrementMax(t *testing.T) {
+	cases := map[string]uint64{
+		"tinyint(4)":            127,
+		"tinyint(3) unsigned":   255,
+		"smallint(6)":           32767,
+		"mediumint(8) unsigned": 16777215,
+		"int(11)":               2147483647,
+		"int unsigned":          4294967295,
+		"bigint(20)":            math.MaxInt64,
+		"bigint(20) unsigned":   math.MaxUint64,
+	}
+	for colType, expected := range cases {
+		col := &tengo.Column{Name: "id", TypeInDB: colType}
+		if actual, ok := AutoIncrementMax(col); !ok || actual != expected {
+			t.Errorf("Expected AutoIncrementMax for type %s to return %d,true; instead found %d,%t", colType, expected, actual, ok)
+		}
+	}
+	col := &tengo.Column{Name: "id", TypeInDB: "varchar(20)"}
+	if _, ok := AutoIncrementMax(col); ok {
+		t.Error("Expected AutoIncrementMax for varchar column to return ok=false, but it returned true")
+	}
+}
+
+func TestAutoIncrementColumn(t *testing.T) {
+	table := &tengo.Table{
+		Columns: []*tengo.Column{{Name: "a"}, {Name: "b", AutoIncrement: true}},
+	}
+	if col := AutoIncrementColumn(table); col == nil || col.Name != "b" {
+		t.Errorf("Unexpected result from AutoIncrementColumn: %+v", col)
+	}
+	table.Columns[1].AutoIncrement = false
+	if col := AutoIncrementColumn(table); col != nil {
+		t.Errorf("Expected AutoIncrementColumn to return nil, instead found %+v", col)
+	}
+}
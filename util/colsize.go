@@ -0,0 +1,101 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/skeema/tengo"
+)
+
+// CharSetMaxBytes returns the maximum number of bytes per character in the
+// supplied character set. Unknown character sets, as well as an empty string
+// (used for non-string columns), are treated as single-byte.
+func CharSetMaxBytes(charSet string) uint64 {
+	switch strings.ToLower(charSet) {
+	case "utf8mb4", "utf16", "utf16le", "utf32", "gb18030":
+		return 4
+	case "utf8", "utf8mb3", "ujis", "eucjpms":
+		return 3
+	case "ucs2", "big5", "sjis", "cp932", "euckr", "gb2312", "gbk":
+		return 2
+	default:
+		return 1
+	}
+}
+
+// ColumnMaxBytes returns the maximum number of bytes needed to store a value
+// of col, based on its type and character set. This does not include the
+// length prefix of variable-length types, nor the table's NULL bitmap. For
+// BLOB, TEXT, JSON, and spatial types, whose values may be stored off-page,
+// ok will be false.
+func ColumnMaxBytes(col *tengo.Column) (max uint64, ok bool) {
+	colType := strings.ToLower(col.TypeInDB)
+	var args []uint64
+	if start, end := strings.IndexByte(colType, '('), strings.IndexByte(colType, ')'); start > -1 && end > start {
+		for _, arg := range strings.Split(colType[start+1:end], ",") {
+			if n, err := strconv.ParseUint(strings.TrimSpace(arg), 10, 64); err == nil {
+				args = append(args, n)
+			}
+		}
+	}
+	arg := func(n int, defaultValue uint64) uint64 {
+		if n < len(args) {
+			return args[n]
+		}
+		return defaultValue
+	}
+	baseType := colType
+	if pos := strings.IndexAny(baseType, "( "); pos > -1 {
+		baseType = baseType[0:pos]
+	}
+
+	switch baseType {
+	case "tinyint", "year":
+		return 1, true
+	case "smallint":
+		return 2, true
+	case "mediumint":
+		return 3, true
+	case "int", "integer", "float":
+		return 4, true
+	case "bigint", "double", "real":
+		return 8, true
+	case "bit":
+		return (arg(0, 1) + 7) / 8, true
+	case "decimal", "numeric":
+		precision, scale := arg(0, 10), arg(1, 0)
+		return decimalBytes(precision-scale) + decimalBytes(scale), true
+	case "date":
+		return 3, true
+	case "time":
+		return 3 + (arg(0, 0)+1)/2, true
+	case "datetime":
+		return 5 + (arg(0, 0)+1)/2, true
+	case "timestamp":
+		return 4 + (arg(0, 0)+1)/2, true
+	case "enum":
+		if strings.Count(colType, "','") >= 255 {
+			return 2, true
+		}
+		return 1, true
+	case "set":
+		members := uint64(strings.Count(colType, "','") + 1)
+		if members > 32 {
+			return 8, true
+		}
+		return (members + 7) / 8, true
+	case "char", "varchar":
+		return arg(0, 1) * CharSetMaxBytes(col.CharSet), true
+	case "binary", "varbinary":
+		return arg(0, 1), true
+	default:
+		return 0, false
+	}
+}
+
+// decimalBytes returns the number of bytes used to store the supplied number
+// of digits in one part (integer or fractional) of a DECIMAL column.
+func decimalBytes(digits uint64) uint64 {
+	leftover := []uint64{0, 1, 1, 2, 2, 3, 3, 4, 4, 4}
+	return (digits/9)*4 + leftover[digits%9]
+}
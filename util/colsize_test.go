@@ -0,0 +1,56 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestColumnMaxBytes(t *testing.T) {
+	cases := []struct {
+		colType  string
+		charSet  string
+		expected uint64
+	}{
+		{"int(10) unsigned", "", 4},
+		{"bigint(20)", "", 8},
+		{"decimal(10,2)", "", 5},
+		{"decimal(20,0)", "", 9},
+		{"bit(10)", "", 2},
+		{"datetime(6)", "", 8},
+		{"timestamp", "", 4},
+		{"enum('a','b','c')", "", 1},
+		{"set('a','b','c','d','e','f','g','h','i')", "", 2},
+		{"char(10)", "latin1", 10},
+		{"varchar(255)", "utf8mb4", 1020},
+		{"varchar(100)", "utf8", 300},
+		{"varbinary(16)", "", 16},
+	}
+	for _, c := range cases {
+		col := &tengo.Column{Name: "c", TypeInDB: c.colType, CharSet: c.charSet}
+		if actual, ok := ColumnMaxBytes(col); !ok || actual != c.expected {
+			t.Errorf("Expected ColumnMaxBytes for type %s %s to return %d,true; instead found %d,%t", c.colType, c.charSet, c.expected, actual, ok)
+		}
+	}
+	for _, colType := range []string{"text", "longblob", "json", "geometry"} {
+		col := &tengo.Column{Name: "c", TypeInDB: colType}
+		if _, ok := ColumnMaxBytes(col); ok {
+			t.Errorf("Expected ColumnMaxBytes for type %s to return ok=false, but it returned true", colType)
+		}
+	}
+}
+
+func TestCharSetMaxBytes(t *testing.T) {
+	cases := map[string]uint64{
+		"utf8mb4": 4,
+		"UTF8":    3,
+		"ucs2":    2,
+		"latin1":  1,
+		"":        1,
+	}
+	for charSet, expected := range cases {
+		if actual := CharSetMaxBytes(charSet); actual != expected {
+			t.Errorf("Expected CharSetMaxBytes(%q) to return %d, instead found %d", charSet, expected, actual)
+		}
+	}
+}
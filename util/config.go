@@ -25,6 +25,8 @@ func AddGlobalOptions(cmd *mybase.Command) {
 	cmd.AddOption(mybase.StringOption("schema", 0, "", "Database schema name").Hidden())
 	cmd.AddOption(mybase.StringOption("ignore-schema", 0, "", "Ignore schemas that match regex").Hidden())
 	cmd.AddOption(mybase.StringOption("ignore-table", 0, "", "Ignore tables that match regex").Hidden())
+	cmd.AddOption(mybase.StringOption("protect-table", 0, "", "Never drop or destructively alter tables that match regex").Hidden())
+	cmd.AddOption(mybase.StringOption("protect-column", 0, "", "Never drop or destructively modify columns that match regex").Hidden())
 	cmd.AddOption(mybase.StringOption("default-character-set", 0, "", "Schema-level default character set").Hidden())
 	cmd.AddOption(mybase.StringOption("default-collation", 0, "", "Schema-level default collation").Hidden())
 	cmd.AddOption(mybase.StringOption("flavor", 0, "", "Database server expressed in format vendor:major.minor, for use in vendor/version specific syntax").Hidden())
@@ -0,0 +1,51 @@
+package util
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// GitChangedFiles returns the absolute paths of all files in the git repo
+// containing dirPath which differ from the supplied ref. This includes
+// committed changes, uncommitted changes to tracked files, deleted files, and
+// untracked files that are not ignored. Returned paths are based on dirPath,
+// rather than on the repo's real path, in case dirPath involves symlinks.
+func GitChangedFiles(dirPath, ref string) (map[string]bool, error) {
+	// Prevent the ref from being interpreted as an option to git diff
+	if strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("Invalid git ref %q: must not begin with a dash", ref)
+	}
+	dirPath, err := filepath.Abs(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	s := &ShellOut{Command: "git rev-parse --show-cdup", Dir: dirPath}
+	cdup, err := s.RunCapture()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to determine git repo containing %s: %s", dirPath, err)
+	}
+	toplevel := filepath.Join(dirPath, strings.TrimRight(cdup, "\n"))
+
+	// Paths in git output are relative to the repo's top-level dir. With
+	// core.quotepath=off, special characters are never quoted or escaped, except
+	// for newlines which are not supported here.
+	commands := []string{
+		fmt.Sprintf("git -c core.quotepath=off diff --name-only %s --", escapeVarValue(ref)),
+		"git -c core.quotepath=off ls-files --others --exclude-standard",
+	}
+	result := make(map[string]bool)
+	for _, command := range commands {
+		s := &ShellOut{Command: command, Dir: toplevel}
+		output, err := s.RunCapture()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to list changed files via %s: %s", command, err)
+		}
+		for _, name := range strings.Split(output, "\n") {
+			if name != "" {
+				result[filepath.Join(toplevel, filepath.FromSlash(name))] = true
+			}
+		}
+	}
+	return result, nil
+}
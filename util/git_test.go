@@ -0,0 +1,54 @@
+package util
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGitChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("Skipping test since git is not available")
+	}
+	repoDir, err := filepath.Abs("test-git-repo")
+	if err != nil {
+		t.Fatalf("Unexpected error from filepath.Abs: %s", err)
+	}
+	os.RemoveAll(repoDir)
+	defer os.RemoveAll(repoDir)
+	setup := &ShellOut{
+		Command: `mkdir -p test-git-repo/sub && cd test-git-repo && git init -q . &&
+			git config user.email test@example.com && git config user.name test &&
+			echo a > unchanged.sql && echo b > sub/modified.sql && echo c > deleted.sql && echo '*.log' > .gitignore &&
+			git add . && git commit -q -m initial && git tag base &&
+			echo b2 > sub/modified.sql && git commit -q -am second &&
+			rm deleted.sql && echo d > 'sub/new file.sql' && echo e > ignored.log`,
+	}
+	if err := setup.Run(); err != nil {
+		t.Skipf("Skipping test since git repo could not be created: %s", err)
+	}
+
+	changed, err := GitChangedFiles(filepath.Join(repoDir, "sub"), "base")
+	if err != nil {
+		t.Fatalf("Unexpected error from GitChangedFiles: %s", err)
+	}
+	expected := map[string]bool{
+		filepath.Join(repoDir, "sub", "modified.sql"): true,
+		filepath.Join(repoDir, "deleted.sql"):         true,
+		filepath.Join(repoDir, "sub", "new file.sql"): true,
+	}
+	if !reflect.DeepEqual(changed, expected) {
+		t.Errorf("Expected changed files %v, instead found %v", expected, changed)
+	}
+
+	if _, err := GitChangedFiles(repoDir, "does-not-exist"); err == nil {
+		t.Error("Expected error from GitChangedFiles with invalid ref, but err was nil")
+	}
+	for _, ref := range []string{"-p", "--output=" + filepath.Join(repoDir, "out.txt")} {
+		if _, err := GitChangedFiles(repoDir, ref); err == nil {
+			t.Errorf("Expected error from GitChangedFiles with ref %q, but err was nil", ref)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/skeema/mybase"
+	"github.com/skeema/tengo"
+)
+
+// Protection indicates which tables and columns may never be dropped or
+// destructively altered, regardless of allow-unsafe or safe-below-size.
+type Protection struct {
+	Table  *regexp.Regexp
+	Column *regexp.Regexp
+}
+
+// ProtectionForConfig returns the Protection configured in cfg via the
+// protect-table and protect-column options.
+func ProtectionForConfig(cfg *mybase.Config) (p Protection, err error) {
+	if p.Table, err = cfg.GetRegexp("protect-table"); err != nil {
+		return
+	}
+	p.Column, err = cfg.GetRegexp("protect-column")
+	return
+}
+
+// ProtectedDiffError is returned by Protection.Check for a diff that affects a
+// protected table or column.
+type ProtectedDiffError struct {
+	Reason string
+}
+
+// Error satisfies the builtin error interface.
+func (e *ProtectedDiffError) Error() string {
+	return e.Reason
+}
+
+// IsProtectedDiff returns true if err represents a diff that has been forbidden
+// because it affects a protected table or column.
+func IsProtectedDiff(err error) bool {
+	_, ok := err.(*ProtectedDiffError)
+	return ok
+}
+
+// Check returns a *ProtectedDiffError if diff would drop a protected table, or
+// would apply an unsafe ALTER TABLE clause to a protected table or protected
+// column. Otherwise, nil is returned.
+func (p Protection) Check(diff tengo.ObjectDiff) error {
+	td, ok := diff.(*tengo.TableDiff)
+	if !ok || (p.Table == nil && p.Column == nil) {
+		return nil
+	}
+	switch td.Type {
+	case tengo.DiffTypeDrop:
+		if p.Table != nil && p.Table.MatchString(td.From.Name) {
+			return &ProtectedDiffError{
+				Reason: fmt.Sprintf("DROP TABLE not permitted on protected table %s", tengo.EscapeIdentifier(td.From.Name)),
+			}
+		}
+	case tengo.DiffTypeAlter:
+		clauses, _ := td.From.Diff(td.To)
+		for _, clause := range clauses {
+			if err := p.checkClause(td.From, clause); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkClause returns a *ProtectedDiffError if clause is unsafe and affects a
+// protected table or column. Otherwise, nil is returned.
+func (p Protection) checkClause(table *tengo.Table, clause tengo.TableAlterClause) error {
+	if unsafer, ok := clause.(tengo.Unsafer); !ok || !unsafer.Unsafe() {
+		return nil
+	}
+	if p.Table != nil && p.Table.MatchString(table.Name) {
+		return &ProtectedDiffError{
+			Reason: fmt.Sprintf("Unsafe or potentially destructive ALTER TABLE not permitted on protected table %s", tengo.EscapeIdentifier(table.Name)),
+		}
+	}
+	var col *tengo.Column
+	switch clause := clause.(type) {
+	case tengo.DropColumn:
+		col = clause.Column
+	case tengo.ModifyColumn:
+		col = clause.OldColumn
+	case tengo.RenameColumn:
+		col = clause.OldColumn
+	}
+	if col != nil && p.Column != nil && p.Column.MatchString(col.Name) {
+		return &ProtectedDiffError{
+			Reason: fmt.Sprintf("Unsafe or potentially destructive change not permitted on protected column %s of table %s", tengo.EscapeIdentifier(col.Name), tengo.EscapeIdentifier(table.Name)),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/skeema/tengo"
+)
+
+func TestProtectionCheck(t *testing.T) {
+	protection := Protection{
+		Table:  regexp.MustCompile("^ledger"),
+		Column: regexp.MustCompile("^amount$"),
+	}
+	from := protectTestSchema(
+		protectTestTable("ledger", "amount", "int(11)", "note", "varchar(20)"),
+		protectTestTable("orders", "amount", "int(11)", "note", "varchar(20)"),
+		protectTestTable("scratch", "amount", "int(11)"),
+	)
+
+	cases := []struct {
+		to          *tengo.Schema
+		expectError bool
+	}{
+		// Dropping an unprotected table, even if it has a protected column
+		{protectTestSchema(from.Tables[0], from.Tables[1]), false},
+		// Dropping a protected table
+		{protectTestSchema(from.Tables[1], from.Tables[2]), true},
+		// Dropping a protected column
+		{protectTestSchema(from.Tables[0], protectTestTable("orders", "note", "varchar(20)"), from.Tables[2]), true},
+		// Dropping an unprotected column
+		{protectTestSchema(from.Tables[0], protectTestTable("orders", "amount", "int(11)"), from.Tables[2]), false},
+		// Safely modifying a protected column
+		{protectTestSchema(from.Tables[0], protectTestTable("orders", "amount", "bigint(20)", "note", "varchar(20)"), from.Tables[2]), false},
+		// Unsafely modifying a protected column
+		{protectTestSchema(from.Tables[0], protectTestTable("orders", "amount", "smallint(6)", "note", "varchar(20)"), from.Tables[2]), true},
+		// Safely altering a protected table
+		{protectTestSchema(protectTestTable("ledger", "amount", "int(11)", "note", "varchar(30)"), from.Tables[1], from.Tables[2]), false},
+		// Unsafely altering a protected table
+		{protectTestSchema(protectTestTable("ledger", "amount", "int(11)", "note", "varchar(10)"), from.Tables[1], from.Tables[2]), true},
+	}
+	for n, c := range cases {
+		var err error
+		for _, diff := range tengo.NewSchemaDiff(from, c.to).ObjectDiffs() {
+			if err = protection.Check(diff); err != nil {
+				break
+			}
+		}
+		if c.expectError && !IsProtectedDiff(err) {
+			t.Errorf("cases[%d]: Expected protected diff error, instead found %v", n, err)
+		} else if !c.expectError && err != nil {
+			t.Errorf("cases[%d]: Unexpected error: %s", n, err)
+		}
+	}
+
+	// Without any protection configured, nothing is forbidden
+	for _, diff := range tengo.NewSchemaDiff(from, protectTestSchema()).ObjectDiffs() {
+		if err := (Protection{}).Check(diff); err != nil {
+			t.Errorf("Unexpected error with no protection configured: %s", err)
+		}
+	}
+}
+
+func protectTestSchema(tables ...*tengo.Table) *tengo.Schema {
+	return &tengo.Schema{
+		Name:      "protecttest",
+		CharSet:   "latin1",
+		Collation: "latin1_swedish_ci",
+		Tables:    tables,
+	}
+}
+
+// protectTestTable returns a table with the supplied name, and columns
+// supplied as alternating pairs of column name and type.
+func protectTestTable(name string, colNamesAndTypes ...string) *tengo.Table {
+	table := &tengo.Table{
+		Name:               name,
+		Engine:             "InnoDB",
+		CharSet:            "latin1",
+		Collation:          "latin1_swedish_ci",
+		CollationIsDefault: true,
+	}
+	for n := 0; n < len(colNamesAndTypes); n += 2 {
+		col := &tengo.Column{
+			Name:     colNamesAndTypes[n],
+			TypeInDB: colNamesAndTypes[n+1],
+			Nullable: true,
+			Default:  tengo.ColumnDefaultNull,
+		}
+		if strings.HasPrefix(col.TypeInDB, "varchar") {
+			col.CharSet, col.Collation, col.CollationIsDefault = table.CharSet, table.Collation, true
+		}
+		table.Columns = append(table.Columns, col)
+	}
+	table.CreateStatement = table.GeneratedCreateStatement(tengo.FlavorMySQL57)
+	return table
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"regexp"
@@ -18,6 +19,7 @@ type ShellOut struct {
 	Dir              string        // Initial working dir for the command if non-empty
 	Timeout          time.Duration // If > 0, kill process after this amount of time
 	CombineOutput    bool          // If true, combine stdout and stderr into a single stream
+	Stdin            io.Reader     // If non-nil, used as STDIN instead of the parent process's STDIN
 	cancelFunc       context.CancelFunc
 }
 
@@ -37,9 +39,16 @@ func (s *ShellOut) cmd() *exec.Cmd {
 	return exec.Command("/bin/sh", "-c", s.Command)
 }
 
+func (s *ShellOut) stdin() io.Reader {
+	if s.Stdin != nil {
+		return s.Stdin
+	}
+	return os.Stdin
+}
+
 // Run shells out to the external command and blocks until it completes. It
 // returns an error if one occurred. STDIN, STDOUT, and STDERR will be
-// redirected to those of the parent process.
+// redirected to those of the parent process, unless s.Stdin is set.
 func (s *ShellOut) Run() error {
 	if s.Command == "" {
 		return errors.New("Attempted to shell out to an empty command string")
@@ -49,7 +58,7 @@ func (s *ShellOut) Run() error {
 		defer s.cancelFunc()
 	}
 	cmd.Dir = s.Dir
-	cmd.Stdin = os.Stdin
+	cmd.Stdin = s.stdin()
 	cmd.Stdout = os.Stdout
 	if s.CombineOutput {
 		cmd.Stderr = os.Stdout
@@ -62,7 +71,8 @@ func (s *ShellOut) Run() error {
 // RunCapture shells out to the external command and blocks until it completes.
 // It returns the command's STDOUT output as a single string, optionally with
 // STDERR if CombineOutput is true; otherwise STDERR is redirected to that of
-// the parent process. STDIN is always redirected from the parent process.
+// the parent process. STDIN is redirected from the parent process, unless
+// s.Stdin is set.
 func (s *ShellOut) RunCapture() (string, error) {
 	if s.Command == "" {
 		return "", errors.New("Attempted to shell out to an empty command string")
@@ -72,7 +82,7 @@ func (s *ShellOut) RunCapture() (string, error) {
 		defer s.cancelFunc()
 	}
 	cmd.Dir = s.Dir
-	cmd.Stdin = os.Stdin
+	cmd.Stdin = s.stdin()
 
 	var out []byte
 	var err error
@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -180,3 +181,15 @@ func TestShellOutCombineOutput(t *testing.T) {
 		}
 	}
 }
+
+func TestShellOutStdin(t *testing.T) {
+	s := &ShellOut{
+		Command: "tr a-z A-Z",
+		Stdin:   strings.NewReader("hello world"),
+	}
+	if output, err := s.RunCapture(); err != nil {
+		t.Errorf("Unexpected error from RunCapture(): %v", err)
+	} else if output != "HELLO WORLD" {
+		t.Errorf("Unexpected output from RunCapture(): %q", output)
+	}
+}
@@ -0,0 +1,80 @@
+package util
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/skeema/mybase"
+)
+
+// TrashTablePrefix is the name prefix used for tables that have been soft-
+// dropped, meaning renamed rather than actually dropped.
+const TrashTablePrefix = "_skeema_trash_"
+
+// Trash table names include the time of the soft-drop, so that a table which
+// is soft-dropped, recreated, and soft-dropped again on the same day does not
+// conflict with its earlier trash table. Older versions only included the
+// date, so both formats are recognized.
+const (
+	trashTimeFormat = "20060102150405"
+	trashDateFormat = "20060102"
+)
+
+const maxIdentifierLength = 64
+
+var reTrashTable = regexp.MustCompile(`^` + TrashTablePrefix + `(\d{8}(?:\d{6})?)_`)
+
+// TrashTableName returns the name that the supplied table should be renamed to
+// when soft-dropping it at the supplied time. If the result would exceed
+// MySQL's maximum identifier length of 64 characters, it is truncated and then
+// suffixed with a short hash of the full original name, so that long names
+// sharing a common prefix still yield distinct trash names.
+func TrashTableName(name string, droppedAt time.Time) string {
+	trashName := fmt.Sprintf("%s%s_%s", TrashTablePrefix, droppedAt.Format(trashTimeFormat), name)
+	if runes := []rune(trashName); len(runes) > maxIdentifierLength {
+		sum := sha1.Sum([]byte(name))
+		suffix := "_" + hex.EncodeToString(sum[:])[0:8]
+		trashName = string(runes[0:maxIdentifierLength-len(suffix)]) + suffix
+	}
+	return trashName
+}
+
+// ParseTrashTableName returns the time that a soft-dropped table was renamed,
+// based on its name. Names from older versions only include the date, in which
+// case the time is midnight of that date. If the supplied name is not a trash
+// table name, ok will be false.
+func ParseTrashTableName(name string) (droppedAt time.Time, ok bool) {
+	matches := reTrashTable.FindStringSubmatch(name)
+	if matches == nil {
+		return droppedAt, false
+	}
+	format := trashTimeFormat
+	if len(matches[1]) == len(trashDateFormat) {
+		format = trashDateFormat
+	}
+	droppedAt, err := time.ParseInLocation(format, matches[1], time.Local)
+	return droppedAt, err == nil
+}
+
+// IsTrashTable returns true if the supplied table name corresponds to a table
+// that has been soft-dropped.
+func IsTrashTable(name string) bool {
+	return reTrashTable.MatchString(name)
+}
+
+// IgnoreTableRegexp returns a regular expression for table names that should
+// be ignored by diff, push, pull, and init. This combines the value of the
+// ignore-table option with the names of soft-dropped tables, which are always
+// ignored.
+func IgnoreTableRegexp(cfg *mybase.Config) (*regexp.Regexp, error) {
+	ignoreTable, err := cfg.GetRegexp("ignore-table")
+	if err != nil {
+		return nil, err
+	} else if ignoreTable == nil {
+		return reTrashTable, nil
+	}
+	return regexp.Compile(fmt.Sprintf("(?:%s)|(?:%s)", reTrashTable, ignoreTable))
+}
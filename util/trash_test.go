@@ -0,0 +1,85 @@
+package util
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/skeema/mybase"
+)
+
+func TestTrashTableName(t *testing.T) {
+	droppedAt := time.Date(2019, time.March, 5, 14, 30, 0, 0, time.Local)
+	if actual := TrashTableName("widgets", droppedAt); actual != "_skeema_trash_20190305143000_widgets" {
+		t.Errorf("Unexpected result from TrashTableName: %s", actual)
+	}
+
+	// Soft-dropping the same table again later on the same day must not collide
+	if TrashTableName("widgets", droppedAt) == TrashTableName("widgets", droppedAt.Add(time.Hour)) {
+		t.Error("Expected TrashTableName to differ for drops at different times on the same day")
+	}
+	longName := strings.Repeat("x", 64)
+	if actual := TrashTableName(longName, droppedAt); len(actual) != 64 || !IsTrashTable(actual) {
+		t.Errorf("Unexpected result from TrashTableName with long name: %s", actual)
+	}
+
+	// Long names sharing a common prefix must not collide, and multibyte chars
+	// must not be split
+	longNames := []string{strings.Repeat("x", 50) + "_first", strings.Repeat("x", 50) + "_second", strings.Repeat("ü", 60)}
+	seen := make(map[string]bool)
+	for _, name := range longNames {
+		actual := TrashTableName(name, droppedAt)
+		if utf8.RuneCountInString(actual) != 64 || !utf8.ValidString(actual) || !IsTrashTable(actual) {
+			t.Errorf("Unexpected result from TrashTableName with long name %s: %s", name, actual)
+		}
+		if seen[actual] {
+			t.Errorf("TrashTableName returned duplicate result %s", actual)
+		}
+		seen[actual] = true
+		if again := TrashTableName(name, droppedAt); again != actual {
+			t.Errorf("TrashTableName not deterministic: %s vs %s", actual, again)
+		}
+	}
+
+	parsed, ok := ParseTrashTableName(TrashTableName("widgets", droppedAt))
+	if !ok || !parsed.Equal(droppedAt) {
+		t.Errorf("Unexpected result from ParseTrashTableName: %s, %t", parsed, ok)
+	}
+
+	// Names from older versions only include the date
+	parsed, ok = ParseTrashTableName("_skeema_trash_20190305_widgets")
+	if !ok || !parsed.Equal(time.Date(2019, time.March, 5, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("Unexpected result from ParseTrashTableName with date-only name: %s, %t", parsed, ok)
+	}
+	for _, name := range []string{"widgets", "_skeema_trash_widgets", "_skeema_trash_2019030_widgets", "_skeema_trash_20191399_widgets", "_skeema_trash_2019030512_widgets", "_skeema_trash_20190305250000_widgets"} {
+		if _, ok := ParseTrashTableName(name); ok {
+			t.Errorf("Expected ParseTrashTableName to fail on %s, but it did not", name)
+		}
+	}
+}
+
+func TestIgnoreTableRegexp(t *testing.T) {
+	assertIgnored := func(cfg *mybase.Config, name string, expected bool) {
+		t.Helper()
+		re, err := IgnoreTableRegexp(cfg)
+		if err != nil {
+			t.Fatalf("Unexpected error from IgnoreTableRegexp: %s", err)
+		}
+		if actual := re.MatchString(name); actual != expected {
+			t.Errorf("Expected IgnoreTableRegexp match on %s to return %t, instead found %t", name, expected, actual)
+		}
+	}
+	cfg := mybase.SimpleConfig(map[string]string{"ignore-table": ""})
+	assertIgnored(cfg, "_skeema_trash_20190305_widgets", true)
+	assertIgnored(cfg, "_widgets", false)
+	cfg = mybase.SimpleConfig(map[string]string{"ignore-table": "^_"})
+	assertIgnored(cfg, "_skeema_trash_20190305_widgets", true)
+	assertIgnored(cfg, "_widgets", true)
+	assertIgnored(cfg, "widgets", false)
+
+	cfg = mybase.SimpleConfig(map[string]string{"ignore-table": "+"})
+	if _, err := IgnoreTableRegexp(cfg); err == nil {
+		t.Error("Expected error from IgnoreTableRegexp with invalid regexp, but err was nil")
+	}
+}
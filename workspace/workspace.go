@@ -16,6 +16,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	log "github.com/sirupsen/logrus"
 	"github.com/skeema/skeema/fs"
+	"github.com/skeema/skeema/util"
 	"github.com/skeema/tengo"
 )
 
@@ -116,20 +117,11 @@ func OptionsForDir(dir *fs.Dir, instance *tengo.Instance) (Options, error) {
 		LockWaitTimeout: 30 * time.Second,
 	}
 	if requestedType == "docker" {
-		opts.Type = TypeLocalDocker
-		opts.Flavor = tengo.NewFlavor(dir.Config.Get("flavor"))
-		if !opts.Flavor.Known() && instance != nil {
-			opts.Flavor = instance.Flavor()
+		flavor := tengo.NewFlavor(dir.Config.Get("flavor"))
+		if !flavor.Known() && instance != nil {
+			flavor = instance.Flavor()
 		}
-		opts.ContainerName = fmt.Sprintf("skeema-%s", strings.Replace(opts.Flavor.String(), ":", "-", -1))
-		if cleanup, err := dir.Config.GetEnum("docker-cleanup", "none", "stop", "destroy"); err != nil {
-			return Options{}, err
-		} else if cleanup == "stop" {
-			opts.CleanupAction = CleanupActionStop
-		} else if cleanup == "destroy" {
-			opts.CleanupAction = CleanupActionDestroy
-		}
-		if opts.DefaultConnParams, err = dir.InstanceDefaultParams(); err != nil {
+		if err := setLocalDockerOptions(&opts, dir, flavor); err != nil {
 			return Options{}, err
 		}
 	} else {
@@ -144,6 +136,40 @@ func OptionsForDir(dir *fs.Dir, instance *tengo.Instance) (Options, error) {
 	return opts, nil
 }
 
+// OptionsForFlavor returns Options for a TypeLocalDocker workspace using the
+// supplied flavor, regardless of the workspace and flavor options configured
+// in dir. Other settings are based on the configuration in dir, in the same
+// manner as OptionsForDir.
+func OptionsForFlavor(dir *fs.Dir, flavor tengo.Flavor) (Options, error) {
+	opts := Options{
+		CleanupAction:   CleanupActionNone,
+		SchemaName:      dir.Config.Get("temp-schema"),
+		LockWaitTimeout: 30 * time.Second,
+	}
+	if err := setLocalDockerOptions(&opts, dir, flavor); err != nil {
+		return Options{}, err
+	}
+	return opts, nil
+}
+
+// setLocalDockerOptions configures opts for a TypeLocalDocker workspace of
+// the supplied flavor, using dir's docker-cleanup option and default
+// connection parameters.
+func setLocalDockerOptions(opts *Options, dir *fs.Dir, flavor tengo.Flavor) (err error) {
+	opts.Type = TypeLocalDocker
+	opts.Flavor = flavor
+	opts.ContainerName = fmt.Sprintf("skeema-%s", strings.Replace(flavor.String(), ":", "-", -1))
+	if cleanup, err := dir.Config.GetEnum("docker-cleanup", "none", "stop", "destroy"); err != nil {
+		return err
+	} else if cleanup == "stop" {
+		opts.CleanupAction = CleanupActionStop
+	} else if cleanup == "destroy" {
+		opts.CleanupAction = CleanupActionDestroy
+	}
+	opts.DefaultConnParams, err = dir.InstanceDefaultParams()
+	return err
+}
+
 // ShutdownFunc is a function that manages final cleanup of a Workspace upon
 // completion of a request or process. It may optionally use args, passed
 // through by Shutdown(), to determine whether or not a Workspace needs to be
@@ -253,8 +279,16 @@ func ExecLogicalSchema(logicalSchema *fs.LogicalSchema, opts Options) (schema *t
 	defer dbRemember.SetMaxOpenConns(0)
 	db.SetMaxOpenConns(10)
 	dbRemember.SetMaxOpenConns(10)
+	// Soft-dropped tables are ignored by all diff operations, so don't bother
+	// creating them in the workspace.
+	creates := make([]*fs.Statement, 0, len(logicalSchema.Creates))
+	for key, stmt := range logicalSchema.Creates {
+		if key.Type != tengo.ObjectTypeTable || !util.IsTrashTable(key.Name) {
+			creates = append(creates, stmt)
+		}
+	}
 	results := make(chan *StatementError)
-	for _, stmt := range logicalSchema.Creates {
+	for _, stmt := range creates {
 		go func(statement *fs.Statement) {
 			if rememberSQLMode[statement.ObjectType] {
 				results <- execStatement(dbRemember, statement)
@@ -263,7 +297,7 @@ func ExecLogicalSchema(logicalSchema *fs.LogicalSchema, opts Options) (schema *t
 			}
 		}(stmt)
 	}
-	for range logicalSchema.Creates {
+	for range creates {
 		if result := <-results; result != nil {
 			statementErrors = append(statementErrors, result)
 		}
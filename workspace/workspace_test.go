@@ -183,6 +183,15 @@ func (s WorkspaceIntegrationSuite) TestOptionsForDir(t *testing.T) {
 	if opts = getOpts("--workspace=docker --flavor=mysql:5.5"); opts.Flavor.String() != "mysql:5.5" {
 		t.Errorf("Unexpected return from OptionsForDir: %+v", opts)
 	}
+
+	// Test OptionsForFlavor, which should override workspace and flavor
+	dir := s.getParsedDir(t, "../testdata/golden/init/mydb/product", "--workspace=temp-schema --flavor=mysql:5.5 --docker-cleanup=stop")
+	opts, err := OptionsForFlavor(dir, tengo.NewFlavor("mysql:8.0"))
+	if err != nil {
+		t.Fatalf("Unexpected error from OptionsForFlavor: %s", err)
+	} else if opts.Type != TypeLocalDocker || opts.Flavor.String() != "mysql:8.0" || opts.ContainerName != "skeema-mysql-8.0" || opts.CleanupAction != CleanupActionStop {
+		t.Errorf("Unexpected return from OptionsForFlavor: %+v", opts)
+	}
 }
 
 // TestPrefab confirms that ExecLogicalSchema still functions properly with a